@@ -15,18 +15,22 @@ import (
 	"Axion/constants"
 	"Axion/evaluator"
 	"Axion/history"
-	"Axion/parser"
+	"Axion/lineedit"
+	"Axion/paths"
 	"Axion/settings"
 	"Axion/tokenizer"
 	"Axion/units"
-	"bufio"
+	"Axion/workspace"
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 const banner = `
@@ -35,7 +39,8 @@ const banner = `
   ╩ ╩┴ └─┴└─┘┘└┘
 `
 
-const (
+// Terminal colors; applyTheme replaces them for the configured theme
+var (
 	colorReset  = "\033[0m"
 	colorRed    = "\033[31m"
 	colorGreen  = "\033[32m"
@@ -58,83 +63,237 @@ var rootCmd = &cobra.Command{
   ` + colorGreen + `✓` + colorReset + ` Built-in mathematical functions and constants
   ` + colorGreen + `✓` + colorReset + ` Calculation history and session management
   ` + colorGreen + `✓` + colorReset + ` Customizable precision and settings`,
-	Run: startREPL,
+	Args:              cobra.ArbitraryArgs,
+	PersistentPreRunE: loadSettings,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Bare expressions (axion "2+2") are evaluated without the REPL
+		if len(args) > 0 {
+			return evalArgs(args)
+		}
+		// Piped or redirected input is run as a script
+		if !interactive && !stdinIsTerminal() {
+			return runScript(os.Stdin, "<stdin>")
+		}
+		startREPL(cmd, args)
+		return nil
+	},
 }
 
 // Execute runs the root command
+// Errors are printed to stderr here; ExitCode maps them to an exit status
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil {
+		reportError(err)
+	}
+	return err
 }
 
 func init() {
-	// Initialize constants system
-	err := constants.Load("constants.json")
-	if err != nil {
-		fmt.Printf(colorYellow+"Warning: Failed to load constants: %v\n"+colorReset, err)
+	// Lines starting with a command run it, so commands cannot be variables
+	evaluator.Commands = argumentCommands
+
+	// Initialize constants system: the user's catalog if present,
+	// otherwise the catalog embedded in the binary
+	if err := loadConstants(); err != nil {
+		warn("Failed to load constants: %v", err)
+	}
+
+	// Load user-defined units from the config directory when present
+	if file := paths.Config("units.json"); paths.Exists(file) {
+		if _, err := units.LoadFile(file); err != nil {
+			warn("Failed to load units from %s: %v", file, err)
+		}
+	}
+
+	// Load imported currency rates when present
+	if file := paths.Data("rates.json"); paths.Exists(file) {
+		if err := loadRates(file); err != nil {
+			warn("Failed to load currency rates: %v", err)
+		}
+	}
+
+	registerSettingFlags(rootCmd)
+	rootCmd.SetGlobalNormalizationFunc(normalizeFlags)
+	registerRunFlags(rootCmd)
+	registerOutputFlag(rootCmd)
+	registerWorkspaceFlag(rootCmd)
+	rootCmd.AddCommand(evalCmd)
+	rootCmd.AddCommand(runCmd)
+	ratesCmd.AddCommand(ratesImportCmd)
+	rootCmd.AddCommand(ratesCmd)
+}
+
+// warn prints a warning to stderr, so that it never mixes with results on
+// stdout; it is colored only when stderr is a terminal
+func warn(format string, args ...any) {
+	message := "Warning: " + fmt.Sprintf(format, args...)
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		message = colorYellow + message + colorReset
+	}
+	fmt.Fprintln(os.Stderr, message)
+}
+
+// loadConstants loads constants.json from the config directory, falling
+// back to the embedded catalog when there is none or it cannot be read
+func loadConstants() error {
+	if file := paths.Config("constants.json"); paths.Exists(file) {
+		err := constants.Load(file)
+		if err == nil {
+			return nil
+		}
+		if defaultErr := constants.LoadDefault(); defaultErr != nil {
+			return defaultErr
+		}
+		return fmt.Errorf("%v (using the built-in catalog)", err)
 	}
+	return constants.LoadDefault()
 }
 
 // startREPL launches the interactive calculator session
 func startREPL(cmd *cobra.Command, args []string) {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	printWelcome()
+	editor := lineedit.New()
+	editor.SetHistory(historyInputs())
+	editor.Complete = completeInput
+	editor.Highlight = highlightInput
+
+	// Structured output leaves stdout to the records
+	prompt := colorCyan + "» " + colorReset
+	if !structured() {
+		printWelcome()
+	} else {
+		prompt = ""
+	}
+	autoRestore()
+	defer autoSave()
 
 	for {
-		fmt.Print(colorCyan + "» " + colorReset)
-
-		if !scanner.Scan() {
-			fmt.Println(colorYellow + "\nGoodbye!" + colorReset)
-			break
+		line, err := editor.ReadLine(prompt)
+		if err == lineedit.ErrInterrupt {
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Printf(colorRed+"Input error: %v\n"+colorReset, err)
+			}
+			if !structured() {
+				fmt.Println(colorYellow + "Goodbye!" + colorReset)
+			}
+			return
 		}
 
-		input := strings.TrimSpace(scanner.Text())
+		if strings.Contains(line, "\n") {
+			// A pasted block of statements
+			if !runBlock(strings.Split(line, "\n")) {
+				return
+			}
+			continue
+		}
 
+		input, err := readContinued(editor, line)
+		if err != nil {
+			continue // Ctrl-C discards the whole input
+		}
 		if input == "" {
 			continue
 		}
+		editor.AddHistory(input)
 
-		switch {
-		case input == "exit" || input == "quit":
-			fmt.Println(colorYellow + "Goodbye!" + colorReset)
+		if input == "paste" {
+			lines, err := readPaste(editor)
+			if err == nil && !runBlock(lines) {
+				return
+			}
+			continue
+		}
+		if !handleInput(input) {
 			return
+		}
+	}
+}
 
-		case input == "clear" || input == "cls":
-			clearScreen()
-			printWelcome()
-			continue
+// handleInput runs one REPL command or expression; it reports false when
+// the input quits the REPL
+func handleInput(input string) bool {
+	switch {
+	case input == "exit" || input == "quit":
+		fmt.Println(colorYellow + "Goodbye!" + colorReset)
+		return false
+
+	case input == "clear" || input == "cls":
+		clearScreen()
+		printWelcome()
+
+	case input == "help":
+		printHelp()
+
+	case input == "variables" || input == "vars":
+		showVariables()
+
+	case input == "history":
+		var err error
+		if structured() {
+			err = writeHistory()
+		} else {
+			err = history.ShowHistory(plainWithUnit)
+		}
+		if err != nil {
+			fmt.Printf(colorRed+"Error displaying history: %v\n"+colorReset, err)
+		}
 
-		case input == "help":
-			printHelp()
-			continue
+	case input == "settings":
+		showSettings()
 
-		case input == "variables" || input == "vars":
-			showVariables()
-			continue
+	case input == "set" || strings.HasPrefix(input, "set "):
+		handleSet(input)
 
-		case input == "history":
-			err := history.ShowHistory()
-			if err != nil {
-				fmt.Printf(colorRed+"Error displaying history: %v\n"+colorReset, err)
-			}
-			continue
+	case strings.HasPrefix(input, "precision "):
+		handlePrecision(input)
 
-		case strings.HasPrefix(input, "precision "):
-			handlePrecision(input)
-			continue
+	case strings.HasPrefix(input, "seed "):
+		handleSeed(input)
 
-		case strings.HasPrefix(input, "convert "):
-			handleConversion(input)
-			continue
+	case input == "paths":
+		showPaths()
 
-		default:
-			handleExpression(input)
-		}
+	case input == "units" || strings.HasPrefix(input, "units "):
+		handleUnits(input)
+
+	case input == "constants" || strings.HasPrefix(input, "constants "):
+		handleConstants(input)
+
+	case input == "rates" || strings.HasPrefix(input, "rates "):
+		handleRates(input)
+
+	case input == "save" || strings.HasPrefix(input, "save "):
+		handleSave(input)
+
+	case input == "load" || strings.HasPrefix(input, "load "):
+		handleLoad(input)
+
+	case input == "convert" || strings.HasPrefix(input, "convert "):
+		handleConversion(input)
+
+	default:
+		handleExpression(input)
 	}
+	return true
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf(colorRed+"Input error: %v\n"+colorReset, err)
+// historyInputs returns the stored history expressions for recall in the
+// line editor, oldest first
+func historyInputs() []string {
+	entries, err := history.Load()
+	if err != nil {
+		return nil
 	}
+	inputs := make([]string, len(entries))
+	for i, entry := range entries {
+		inputs[i] = entry.Expression
+	}
+	return inputs
 }
 
 // printWelcome displays the welcome banner
@@ -158,6 +317,11 @@ func printHelp() {
 	fmt.Printf("│ %-25s %s\n", colorGreen+"clear"+colorReset, "Clear terminal screen")
 	fmt.Printf("│ %-25s %s\n", colorGreen+"variables"+colorReset, "Show all stored variables")
 	fmt.Printf("│ %-25s %s\n", colorGreen+"history"+colorReset, "Display calculation history")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"paths"+colorReset, "Show the config and data files in use")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"save [name]"+colorReset, "Save variables, settings and units")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"load [name]"+colorReset, "Restore a saved workspace")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"paste"+colorReset, "Enter a block of statements, ended by 'end'")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Continuation:"+colorReset, "End a line with \\, an operator or open '('")
 	fmt.Println(colorYellow + "└──────────────────────────────────────────────────────────┘" + colorReset)
 	fmt.Println()
 
@@ -168,26 +332,47 @@ func printHelp() {
 	fmt.Printf("│ %-25s %s\n", colorBold+"Utility:"+colorReset, "abs, ceil, floor, round, sign")
 	fmt.Printf("│ %-25s %s\n", colorBold+"Statistical:"+colorReset, "mean, median, mode, sum, product")
 	fmt.Printf("│ %-25s %s\n", colorBold+"Other:"+colorReset, "max, min, mod, ! (factorial)")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Random:"+colorReset, "rand, randint, randn, choice")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Monte Carlo:"+colorReset, "simulate(expr, n)")
 	fmt.Println(colorPurple + "└──────────────────────────────────────────────────────────┘" + colorReset)
 	fmt.Println()
 
 	fmt.Println(colorBlue + "┌─ VARIABLES & CONSTANTS ──────────────────────────────────┐" + colorReset)
 	fmt.Printf("│ %-25s %s\n", colorBold+"Assignment:"+colorReset, "x = 5, area = pi * r^2")
-	fmt.Printf("│ %-25s %s\n", colorBold+"Constants:"+colorReset, "pi, e, phi, c, G, h")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Read-only:"+colorReset, "const g = 9.81 m/s^2")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Shadow a constant:"+colorReset, "force pi = 3")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Earlier results:"+colorReset, "ans or _, $3 for [3]:, @h12 for history")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Constants:"+colorReset, "pi, e, phi, c, G, h, hbar, NA")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Namespaced:"+colorReset, "math.pi, phys.c, phys.me * phys.c^2")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"constants [search]"+colorReset, "List or search the constants catalog")
 	fmt.Println(colorBlue + "└──────────────────────────────────────────────────────────┘" + colorReset)
 	fmt.Println()
 
 	fmt.Println(colorGreen + "┌─ UNIT CONVERSION ────────────────────────────────────────┐" + colorReset)
 	fmt.Printf("│ %-25s %s\n", colorBold+"Syntax:"+colorReset, "convert <value> <from> to <to>")
-	fmt.Printf("│ %-25s %s\n", colorBold+"Length:"+colorReset, "m, cm, mm, km, in, ft, yd, mi")
-	fmt.Printf("│ %-25s %s\n", colorBold+"Weight:"+colorReset, "kg, g, mg, lb, oz, ton")
-	fmt.Printf("│ %-25s %s\n", colorBold+"Time:"+colorReset, "s, ms, min, h, d")
+	printUnitCategories()
 	fmt.Printf("│ %-25s %s\n", colorBold+"Example:"+colorReset, colorCyan+"convert 100 cm to m"+colorReset)
+	fmt.Printf("│ %-25s %s\n", colorBold+"Compound:"+colorReset, colorCyan+"convert 9.81 kg*m/s^2 to N"+colorReset)
+	fmt.Printf("│ %-25s %s\n", colorBold+"Best unit:"+colorReset, colorCyan+"convert 93784 s to auto"+colorReset)
+	fmt.Printf("│ %-25s %s\n", colorBold+"Mixed units:"+colorReset, colorCyan+"convert 1.75 m to ft+in"+colorReset)
+	fmt.Printf("│ %-25s %s\n", colorBold+"In expressions:"+colorReset, "5 km + 300 m, 60 mi / 2 h in km/h")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"units"+colorReset, "List all unit categories")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"units load <file>"+colorReset, "Load custom units from a JSON file")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"rates"+colorReset, "Show currency rates and their date")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"rates import <file>"+colorReset, "Import a JSON/CSV rate table")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"rates maxage <days>"+colorReset, "Flag rates older than this")
 	fmt.Println(colorGreen + "└──────────────────────────────────────────────────────────┘" + colorReset)
 	fmt.Println()
 
 	fmt.Println(colorYellow + "┌─ SETTINGS ───────────────────────────────────────────────┐" + colorReset)
-	fmt.Printf("│ %-25s %s\n", colorGreen+"precision <n>"+colorReset, "Set decimal precision (0-20)")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"precision <n>"+colorReset, "Set displayed digits (0-20)")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"set display <mode>"+colorReset, "auto, fixed, sig, sci, eng, si")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"set locale <name>"+colorReset, "plain, en, de (1.234,5; args with ;), fr, ch")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"seed <n>"+colorReset, "Seed the random generator")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"settings"+colorReset, "Show all settings")
+	fmt.Printf("│ %-25s %s\n", colorGreen+"set <key> <value>"+colorReset, "Change and save a setting")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Keys:"+colorReset, "precision, angle, display, theme, locale, units,")
+	fmt.Printf("│ %-25s %s\n", "", "history.limit, rates.max_age")
 	fmt.Println(colorYellow + "└──────────────────────────────────────────────────────────┘" + colorReset)
 	fmt.Println()
 
@@ -201,40 +386,154 @@ func printHelp() {
 	fmt.Println()
 }
 
+// printUnitCategories lists every unit category and its symbols,
+// marking units that accept prefixes with an asterisk
+func printUnitCategories() {
+	for _, category := range units.Categories() {
+		symbols := make([]string, len(category.Units))
+		for i, u := range category.Units {
+			symbols[i] = u.Symbol
+			if u.Prefixes != 0 {
+				symbols[i] += "*"
+			}
+		}
+		fmt.Printf("│ %-25s %s\n", colorBold+category.Name+":"+colorReset, strings.Join(symbols, ", "))
+	}
+	fmt.Printf("│ %-25s %s\n", colorBold+"Prefixes (*):"+colorReset, "y z a f p n u/µ m c d da h k M G T P E Z Y")
+	fmt.Printf("│ %-25s %s\n", colorBold+"Binary (B, bit):"+colorReset, "Ki Mi Gi Ti Pi Ei (e.g. MiB, Gibit)")
+}
+
 // clearScreen clears the terminal display
 func clearScreen() {
 	fmt.Print("\033[H\033[2J")
 }
 
-// formatResult formats numerical results with proper precision
+// formatResult formats numerical results in the configured display mode
 func formatResult(result float64) string {
+	text := plainResult(result)
 	if math.IsNaN(result) {
 		return colorRed + "undefined (NaN)" + colorReset
-	} else if math.IsInf(result, 1) {
-		return colorYellow + "+∞" + colorReset
-	} else if math.IsInf(result, -1) {
-		return colorYellow + "-∞" + colorReset
-	} else {
-		format := fmt.Sprintf("%%.%dg", settings.Precision)
-		return colorGreen + fmt.Sprintf(format, result) + colorReset
+	} else if math.IsInf(result, 0) {
+		return colorYellow + text + colorReset
+	}
+	return colorGreen + text + colorReset
+}
+
+// formatQuantity formats a result together with its display unit
+func formatQuantity(q units.Quantity) string {
+	value, unit := q.Display()
+	return formatValueUnit(value, unit)
+}
+
+// formatValueUnit formats a value in unit; in si display mode the SI prefix
+// moves into the unit (1500 m is shown as 1.50 km)
+func formatValueUnit(value float64, unit string) string {
+	if unit == "" {
+		return formatResult(value)
 	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return formatResult(value) + " " + colorGreen + unit + colorReset
+	}
+	text, unit := plainValueUnit(value, unit)
+	return colorGreen + text + " " + unit + colorReset
+}
+
+// plainValueUnit formats a value in unit without colors, returning the
+// number and the unit to show
+func plainValueUnit(value float64, unit string) (string, string) {
+	if unit == "" || math.IsNaN(value) || math.IsInf(value, 0) || settings.DisplayMode != "si" {
+		return plainResult(value), unit
+	}
+	text, unit := units.FormatWithUnit(value, unit, settings.DisplayMode, settings.Precision)
+	return units.Localize(text, units.Locales[settings.Locale]), unit
+}
+
+// plainWithUnit formats a value in unit without colors as one string
+func plainWithUnit(value float64, unit string) string {
+	text, unit := plainValueUnit(value, unit)
+	return strings.TrimSpace(text + " " + unit)
+}
+
+// plainResult formats a number in the display mode and locale without colors
+func plainResult(result float64) string {
+	return units.FormatLocale(result, settings.DisplayMode, settings.Precision, units.Locales[settings.Locale])
 }
 
 // showVariables displays all currently stored variables
 func showVariables() {
+	names := make([]string, 0, len(evaluator.Vars))
+	for name := range evaluator.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if structured() {
+		writeVariables(names, evaluator.Vars)
+		return
+	}
+
 	if len(evaluator.Vars) == 0 {
 		fmt.Println(colorYellow + "No variables defined." + colorReset)
 		return
 	}
 
 	fmt.Println(colorCyan + "┌─ Stored Variables ───────────────────────────────────────┐" + colorReset)
-	for name, value := range evaluator.Vars {
-		fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" = %s\n", name, formatResult(value))
+
+	for _, name := range names {
+		notes := ""
+		if evaluator.ReadOnly[name] {
+			notes += colorBlue + " (const)" + colorReset
+		}
+		if builtin := evaluator.Shadowed(name); builtin != "" {
+			notes += colorYellow + " shadows " + builtin + colorReset
+		}
+		fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" = %s%s\n", name, formatQuantity(evaluator.Vars[name]), notes)
 	}
 	fmt.Println(colorCyan + "└──────────────────────────────────────────────────────────┘" + colorReset)
 	fmt.Println()
 }
 
+// showPaths displays the directories and files Axion reads and writes
+func showPaths() {
+	describe := func(file string) string {
+		switch {
+		case file == "":
+			return colorRed + "unavailable" + colorReset
+		case paths.Exists(file):
+			return file
+		default:
+			return file + colorDim + " (not present)" + colorReset
+		}
+	}
+
+	fmt.Println(colorCyan + "┌─ PATHS ──────────────────────────────────────────────────┐" + colorReset)
+	if home := os.Getenv(paths.HomeEnv); home != "" {
+		fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", paths.HomeEnv, home)
+	}
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "config dir", paths.ConfigDir())
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "data dir", paths.DataDir())
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "constants", constants.Loaded)
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "units", describe(paths.Config("units.json")))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "rates", describe(paths.Data("rates.json")))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "history", describe(history.File))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "workspaces", describe(workspace.Dir()))
+	fmt.Println(colorCyan + "└──────────────────────────────────────────────────────────┘" + colorReset)
+}
+
+// showSimulation displays the summary statistics of a simulate call
+func showSimulation(stats *evaluator.SimulationStats) {
+	fmt.Printf(colorCyan+"┌─ Simulation (%d runs) ───────────────────────────────────┐\n"+colorReset, stats.N)
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "mean", formatResult(stats.Mean))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "stddev", formatResult(stats.StdDev))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "min", formatResult(stats.Min))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "p5", formatResult(stats.P5))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "p25", formatResult(stats.P25))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "p50", formatResult(stats.P50))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "p75", formatResult(stats.P75))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "p95", formatResult(stats.P95))
+	fmt.Printf(colorCyan+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", "max", formatResult(stats.Max))
+	fmt.Println(colorCyan + "└──────────────────────────────────────────────────────────┘" + colorReset)
+}
+
 // handlePrecision processes precision setting commands
 func handlePrecision(input string) {
 	parts := strings.Fields(input)
@@ -244,74 +543,216 @@ func handlePrecision(input string) {
 		return
 	}
 
-	precision, err := strconv.Atoi(parts[1])
-	if err != nil {
+	if _, err := strconv.Atoi(parts[1]); err != nil {
 		fmt.Printf(colorRed+"Invalid number: %s\n"+colorReset, parts[1])
 		return
 	}
 
-	if err := settings.Set(precision); err != nil {
+	if err := changeSetting("precision", parts[1]); err != nil {
 		fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
 		return
 	}
 
-	fmt.Printf(colorGreen+"Precision set to %d decimal places\n"+colorReset, settings.Precision)
+	if settings.DisplayMode == "fixed" {
+		fmt.Printf(colorGreen+"Precision set to %d decimal places\n"+colorReset, settings.Precision)
+	} else {
+		fmt.Printf(colorGreen+"Precision set to %d significant digits (%s display)\n"+colorReset, settings.Precision, settings.DisplayMode)
+	}
+}
+
+// handleSeed processes random seed commands
+func handleSeed(input string) {
+	parts := strings.Fields(input)
+	if len(parts) != 2 {
+		fmt.Println(colorRed + "Usage: " + colorReset + "seed <number>")
+		fmt.Println(colorDim + "   Example: seed 42" + colorReset)
+		return
+	}
+
+	seed, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		fmt.Printf(colorRed+"Invalid seed: %s\n"+colorReset, parts[1])
+		return
+	}
+
+	evaluator.Seed(seed)
+	fmt.Printf(colorGreen+"Random seed set to %d\n"+colorReset, seed)
+}
+
+// handleUnits lists unit categories or loads custom units from a file
+func handleUnits(input string) {
+	parts := strings.Fields(input)
+	switch {
+	case len(parts) == 1:
+		fmt.Println(colorGreen + "┌─ UNITS ──────────────────────────────────────────────────┐" + colorReset)
+		printUnitCategories()
+		fmt.Println(colorGreen + "└──────────────────────────────────────────────────────────┘" + colorReset)
+
+	case len(parts) == 3 && parts[1] == "load":
+		n, err := units.LoadFile(parts[2])
+		if err != nil {
+			fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
+			return
+		}
+		fmt.Printf(colorGreen+"Loaded %d units from %s\n"+colorReset, n, parts[2])
+
+	default:
+		fmt.Println(colorRed + "Usage: " + colorReset + "units | units load <file>")
+		if file := paths.Config("units.json"); file != "" {
+			fmt.Println(colorDim + "   Units in " + file + " are loaded at startup" + colorReset)
+		}
+	}
+}
+
+// handleConstants lists the constants catalog, optionally filtered by a search term
+func handleConstants(input string) {
+	query := strings.TrimSpace(strings.TrimPrefix(input, "constants"))
+	matches := constants.Search(query)
+	if len(matches) == 0 {
+		fmt.Printf(colorYellow+"No constants match %q\n"+colorReset, query)
+		return
+	}
+
+	fmt.Println(colorBlue + "┌─ CONSTANTS ──────────────────────────────────────────────┐" + colorReset)
+	for _, c := range matches {
+		value := formatResult(c.Value)
+		if c.Unit != "" {
+			value += " " + colorGreen + c.Unit + colorReset
+		}
+		if c.Uncertainty != 0 {
+			value += colorDim + fmt.Sprintf(" ± %g", c.Uncertainty) + colorReset
+		} else if c.Namespace == "phys" {
+			value += colorDim + " (exact)" + colorReset
+		}
+		fmt.Printf(colorBlue+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %s\n", c.Key(), value)
+
+		details := c.Name
+		if len(c.Aliases) > 0 {
+			details += " · aliases: " + strings.Join(c.Aliases, ", ")
+		}
+		if c.Source != "" {
+			details += " · " + c.Source
+		}
+		fmt.Printf(colorBlue+"│ "+colorReset+"%-15s "+colorDim+"%s"+colorReset+"\n", "", details)
+		if c.Description != "" {
+			fmt.Printf(colorBlue+"│ "+colorReset+"%-15s "+colorDim+"%s"+colorReset+"\n", "", c.Description)
+		}
+	}
+	fmt.Println(colorBlue + "└──────────────────────────────────────────────────────────┘" + colorReset)
 }
 
 // handleConversion processes unit conversion commands
+// Units may be compound expressions and may contain spaces (kg * m / s^2)
 func handleConversion(input string) {
 	parts := strings.Fields(input)
-	if len(parts) != 5 || parts[3] != "to" {
+	split := -1
+	for i := 3; i < len(parts)-1; i++ {
+		if parts[i] == "to" {
+			split = i
+			break
+		}
+	}
+	if split < 0 && structured() {
+		writeRecord(errorRecord(input, &ExitError{Code: exitUsage, Err: fmt.Errorf("usage: convert <value> <from> to <to>")}))
+		return
+	}
+	if split < 0 {
 		fmt.Println(colorRed + "Usage: " + colorReset + "convert <value> <from> to <to>")
-		fmt.Println(colorDim + "   Example: convert 10 km to m" + colorReset)
+		fmt.Println(colorDim + "   Example: convert 10 km to m, convert 100 km/h to m/s" + colorReset)
+		fmt.Println(colorDim + "   Targets: auto (best unit) or ft+in (mixed units)" + colorReset)
+		printUnitCategories()
 		return
 	}
 
 	valueStr := parts[1]
-	fromUnit := parts[2]
-	toUnit := parts[4]
+	fromUnit := strings.Join(parts[2:split], " ")
+	toUnit := strings.Join(parts[split+1:], " ")
 
 	var value float64
-	_, err := fmt.Sscanf(valueStr, "%f", &value)
+	_, err := fmt.Sscanf(tokenizer.NormalizeNumber(valueStr), "%f", &value)
 	if err != nil {
+		if structured() {
+			writeRecord(errorRecord(input, &ExitError{Code: exitSyntax, Err: fmt.Errorf("invalid number: %s", valueStr)}))
+			return
+		}
 		fmt.Printf(colorRed+"Invalid number: %s\n"+colorReset, valueStr)
 		return
 	}
 
-	result, err := units.Convert(value, fromUnit, toUnit)
+	var converted []units.Part
+	switch {
+	case strings.EqualFold(toUnit, "auto"):
+		converted, err = units.Auto(value, fromUnit)
+	case strings.Contains(toUnit, "+"):
+		targets := strings.Split(toUnit, "+")
+		for i := range targets {
+			targets[i] = strings.TrimSpace(targets[i])
+		}
+		converted, err = units.Breakdown(value, fromUnit, targets)
+	default:
+		var result float64
+		result, err = units.Convert(value, fromUnit, toUnit)
+		converted = []units.Part{{Value: result, Unit: toUnit}}
+	}
 	if err != nil {
+		if structured() {
+			writeRecord(errorRecord(input, classify(err)))
+			return
+		}
 		fmt.Printf(colorRed+"Conversion error: %v\n"+colorReset, err)
 		return
 	}
+	if structured() {
+		// One record per part of a mixed-unit breakdown
+		for _, part := range converted {
+			writeRecord(valueRecord(input, part.Value, part.Unit))
+		}
+		return
+	}
+
+	fmt.Printf(colorBold+"%s"+colorReset+" = %s\n",
+		formatValueUnit(value, fromUnit), formatParts(converted))
+	if source, err := units.Parse(fromUnit); err == nil && isCurrency(source.Dim) {
+		fmt.Println(ratesNotice())
+	}
+}
 
-	fmt.Printf(colorBold+"%s %s"+colorReset+" = "+colorGreen+"%s %s\n"+colorReset,
-		formatResult(value), fromUnit,
-		formatResult(result), toUnit)
+// formatParts formats a conversion result made of one or more unit parts
+func formatParts(converted []units.Part) string {
+	formatted := make([]string, len(converted))
+	for i, part := range converted {
+		formatted[i] = formatValueUnit(part.Value, part.Unit)
+	}
+	return strings.Join(formatted, " ")
 }
 
 // handleExpression processes mathematical expressions
 func handleExpression(input string) {
-	tokens, err := tokenizer.Tokenize(input)
-	if err != nil {
-		fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
-		return
-	}
-	p := parser.Parser{Tokens: tokens}
-	ast, err := p.ParseExpression()
+	result, err := evaluate(input)
 	if err != nil {
+		if structured() {
+			writeRecord(errorRecord(input, err))
+			return
+		}
 		fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
 		return
 	}
 
-	result, err := evaluator.Eval(ast)
-	if err != nil {
-		fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
-		return
+	n := evaluator.Record(result)
+	if structured() {
+		writeRecord(quantityRecord(input, result))
+	} else {
+		if evaluator.LastSimulation != nil {
+			showSimulation(evaluator.LastSimulation)
+		}
+		fmt.Printf(colorBold+"[%d]: "+colorReset+"%s\n", n, formatQuantity(result))
+		if isCurrency(result.Dim) {
+			fmt.Println(ratesNotice())
+		}
 	}
 
-	fmt.Printf(colorBold+"Result: "+colorReset+"%s\n", formatResult(result))
-
-	if err := history.AddHistory(input, result); err != nil {
-		fmt.Printf(colorYellow+"Warning: Failed to save to history: %v\n"+colorReset, err)
+	value, unit := result.Display()
+	if err := history.AddHistory(input, value, unit); err != nil {
+		warn("Failed to save to history: %v", err)
 	}
 }
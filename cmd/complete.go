@@ -0,0 +1,181 @@
+/*
+Axion CLI Calculator - Tab Completion
+=====================================
+
+This file tells the line editor what Tab completes, based on where the
+cursor is:
+
+    »  hi<Tab>                  REPL commands, functions, variables, constants
+    »  set pre<Tab>             setting keys
+    »  load pro<Tab>            saved workspaces
+    »  convert 5 k<Tab>         unit names (km, kg, kPa, ...)
+    »  convert 5 km to m<Tab>   unit names after "to" (and "in")
+    »  2 * sq<Tab>              functions (completed with "("), variables and
+                                constants, including namespaced keys (phys.c)
+    »  log(100, <Tab>           the signature of the enclosing call: log(x[, base])
+*/
+
+package cmd
+
+import (
+	"Axion/constants"
+	"Axion/evaluator"
+	"Axion/lineedit"
+	"Axion/settings"
+	"Axion/tokenizer"
+	"Axion/units"
+	"Axion/workspace"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// replCommands lists the commands completed at the start of a line
+var replCommands = []string{
+	"clear", "constants", "convert", "exit", "help", "history", "load", "paste", "paths",
+	"precision", "quit", "rates", "save", "seed", "set", "settings", "units", "vars", "variables",
+}
+
+// completeInput returns the completions at pos in line
+func completeInput(line []rune, pos int) lineedit.Completion {
+	before := line[:pos]
+	start := pos
+	for start > 0 && isNameRune(before[start-1]) {
+		start--
+	}
+	prefix := string(before[start:])
+	head := string(before[:start])
+	fields := strings.Fields(head)
+	c := lineedit.Completion{Start: start, Hint: signatureHint(before)}
+
+	switch {
+	case len(fields) == 0:
+		c.Candidates = matching(prefix, replCommands)
+		if prefix != "" {
+			c.Candidates = append(c.Candidates, expressionNames(prefix)...)
+		}
+	case fields[0] == "set" && len(fields) == 1:
+		c.Candidates = matching(prefix, settings.Keys())
+	case (fields[0] == "load" || fields[0] == "save") && len(fields) == 1:
+		c.Candidates = matching(prefix, workspace.Names())
+	case fields[0] == "convert":
+		if len(fields) >= 2 {
+			c.Candidates = units.Completions(prefix)
+			if len(fields) >= 3 && !containsField(fields, "to") {
+				c.Candidates = append(matching(prefix, []string{"to"}), c.Candidates...)
+			}
+		}
+	case isUnitPosition(head):
+		c.Candidates = units.Completions(prefix)
+	case prefix != "":
+		c.Candidates = expressionNames(prefix)
+	}
+	c.Candidates = unique(c.Candidates)
+	return c
+}
+
+// isUnitPosition reports whether a unit is expected after head: following
+// "to" or "in", or directly after a number (5 km)
+func isUnitPosition(head string) bool {
+	trimmed := strings.TrimSpace(head)
+	if trimmed == "" || trimmed == head {
+		return false
+	}
+	fields := strings.Fields(trimmed)
+	last := fields[len(fields)-1]
+	if last == "to" || last == "in" {
+		return true
+	}
+	return unicode.IsDigit([]rune(trimmed)[len([]rune(trimmed))-1])
+}
+
+// expressionNames returns the functions, variables and constants starting
+// with prefix; functions are completed with their opening parenthesis
+func expressionNames(prefix string) []string {
+	var names []string
+	for _, name := range matching(prefix, tokenizer.Functions()) {
+		names = append(names, name+"(")
+	}
+	if len(evaluator.Results) > 0 {
+		names = append(names, matching(prefix, []string{"ans"})...)
+	}
+	for name := range evaluator.Vars {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	for _, c := range constants.Catalog {
+		names = append(names, matching(prefix, append([]string{c.Key()}, c.Aliases...))...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// signatureHint returns the signature of the call enclosing the end of
+// before, or "" when the cursor is not inside a known function call
+func signatureHint(before []rune) string {
+	var open []int
+	for i, r := range before {
+		switch r {
+		case '(':
+			open = append(open, i)
+		case ')':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if len(open) == 0 {
+		return ""
+	}
+	end := open[len(open)-1]
+	start := end
+	for start > 0 && isNameRune(before[start-1]) {
+		start--
+	}
+	if signature, ok := evaluator.Signatures[string(before[start:end])]; ok {
+		return colorDim + signature + colorReset
+	}
+	return ""
+}
+
+// matching returns the words starting with prefix
+func matching(prefix string, words []string) []string {
+	var found []string
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+// unique drops repeated candidates, keeping the first occurrence
+func unique(words []string) []string {
+	seen := make(map[string]bool)
+	var kept []string
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			kept = append(kept, w)
+		}
+	}
+	return kept
+}
+
+// containsField reports whether fields contains word
+func containsField(fields []string, word string) bool {
+	for _, f := range fields {
+		if f == word {
+			return true
+		}
+	}
+	return false
+}
+
+// isNameRune reports whether r can be part of a name being completed,
+// including namespaced constants (phys.c) and unit symbols (°C, m²)
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' ||
+		r == '°' || r == '′' || r == '″' || r == '²' || r == '³'
+}
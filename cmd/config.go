@@ -0,0 +1,197 @@
+/*
+Axion CLI Calculator - Configuration Commands
+=============================================
+
+This file connects the settings module to the CLI. Settings are read from
+config.yaml in the config directory (or the file given with --config) before
+any command runs, then command-line flags override them for that invocation
+only. The REPL commands `set <key> <value>` and `settings` change and list
+them; `set` also writes the new value to the config file, leaving settings
+that were only overridden by flags untouched there.
+*/
+
+package cmd
+
+import (
+	"Axion/paths"
+	"Axion/settings"
+	"Axion/tokenizer"
+	"Axion/units"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configPath is the config file in use; --config replaces the default
+var configPath string
+
+// settingFlags maps command-line flags to the settings they override
+var settingFlags = map[string]string{
+	"precision":     "precision",
+	"angle":         "angle",
+	"display":       "display",
+	"theme":         "theme",
+	"locale":        "locale",
+	"units":         "units",
+	"history-limit": "history.limit",
+}
+
+// overridden records the settings given on the command line
+var overridden = make(map[string]bool)
+
+// registerSettingFlags adds the config and setting override flags to cmd
+func registerSettingFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&configPath, "config", "", "config file (default "+paths.Config("config.yaml")+")")
+	flags.Int("precision", settings.Precision, "digits shown, decimal places in fixed mode (0-20)")
+	flags.String("angle", settings.AngleMode, "angle mode for trigonometry (deg, rad)")
+	flags.String("display", settings.DisplayMode, "number display mode ("+strings.Join(settings.DisplayModes, ", ")+")")
+	flags.String("theme", settings.Theme, "color theme ("+strings.Join(settings.Themes, ", ")+")")
+	flags.String("locale", settings.Locale, "number separators ("+strings.Join(settings.LocaleNames, ", ")+")")
+	flags.String("units", "", "unit system for auto conversion (metric, imperial)")
+	flags.Int("history-limit", settings.HistoryLimit, "history entries kept (0 = unlimited)")
+}
+
+// loadSettings reads the config file and applies command-line overrides,
+// then opens the --workspace workspace
+func loadSettings(cmd *cobra.Command, args []string) error {
+	if configPath == "" {
+		configPath = paths.Config("config.yaml")
+	}
+
+	config := settings.Defaults()
+	if configPath != "" {
+		var err error
+		if config, err = settings.Read(configPath); err != nil {
+			warn("%v (using defaults)", err)
+		}
+	}
+
+	var flagErr error
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		key, ok := settingFlags[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		if err := config.Set(key, f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("--%s: %w", f.Name, err)
+			return
+		}
+		overridden[key] = true
+	})
+	if flagErr != nil {
+		return flagErr
+	}
+
+	settings.Apply(config)
+	applySettings()
+	if err := checkOutputFormat(); err != nil {
+		return err
+	}
+	if err := openWorkspace(); err != nil {
+		return &ExitError{Code: exitError, Err: err}
+	}
+	return nil
+}
+
+// applySettings pushes settings into the packages that do not read them directly
+func applySettings() {
+	applyTheme(settings.Theme)
+	units.PreferredSystem = settings.UnitSystem
+	tokenizer.DecimalComma = units.Locales[settings.Locale].Decimal == ","
+}
+
+// plainOutput keeps colors off whatever the theme, for output read by
+// programs rather than people
+var plainOutput bool
+
+// applyTheme sets the REPL colors for the named theme
+func applyTheme(name string) {
+	if plainOutput {
+		name = "mono"
+	}
+	switch name {
+	case "mono":
+		colorReset, colorRed, colorGreen, colorYellow, colorBlue = "", "", "", "", ""
+		colorPurple, colorCyan, colorWhite, colorBold, colorDim = "", "", "", "", ""
+	case "bright":
+		colorReset, colorRed, colorGreen, colorYellow, colorBlue = "\033[0m", "\033[91m", "\033[92m", "\033[93m", "\033[94m"
+		colorPurple, colorCyan, colorWhite, colorBold, colorDim = "\033[95m", "\033[96m", "\033[97m", "\033[1m", "\033[2m"
+	default:
+		colorReset, colorRed, colorGreen, colorYellow, colorBlue = "\033[0m", "\033[31m", "\033[32m", "\033[33m", "\033[34m"
+		colorPurple, colorCyan, colorWhite, colorBold, colorDim = "\033[35m", "\033[36m", "\033[37m", "\033[1m", "\033[2m"
+	}
+}
+
+// changeSetting applies a setting for this session and saves it to the config file
+func changeSetting(key, value string) error {
+	current := settings.Current()
+	if err := current.Set(key, value); err != nil {
+		return err
+	}
+	settings.Apply(current)
+	applySettings()
+	delete(overridden, key)
+
+	if configPath == "" {
+		return fmt.Errorf("no config directory available; setting applies to this session only")
+	}
+	saved, err := settings.Read(configPath)
+	if err != nil {
+		return err
+	}
+	if err := saved.Set(key, value); err != nil {
+		return err
+	}
+	if err := settings.Write(configPath, saved); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
+
+// handleSet processes the REPL set command
+func handleSet(input string) {
+	parts := strings.Fields(input)
+	if len(parts) != 3 {
+		fmt.Println(colorRed + "Usage: " + colorReset + "set <key> <value>")
+		fmt.Println(colorDim + "   Example: set angle rad, set history.limit 500" + colorReset)
+		fmt.Println(colorDim + "   Keys: " + strings.Join(settings.Keys(), ", ") + colorReset)
+		return
+	}
+
+	if err := changeSetting(parts[1], parts[2]); err != nil {
+		fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
+		return
+	}
+	current := settings.Current()
+	value, _ := current.Get(parts[1])
+	fmt.Printf(colorGreen+"%s set to %s\n"+colorReset, strings.ToLower(parts[1]), displaySetting(value))
+}
+
+// showSettings lists every setting with its current value
+func showSettings() {
+	current := settings.Current()
+	fmt.Println(colorYellow + "┌─ SETTINGS ───────────────────────────────────────────────┐" + colorReset)
+	for _, key := range settings.Keys() {
+		value, _ := current.Get(key)
+		note := colorDim + settings.Help(key) + colorReset
+		if overridden[key] {
+			note += colorYellow + " (command line)" + colorReset
+		}
+		fmt.Printf(colorYellow+"│ "+colorReset+colorBold+"%-15s"+colorReset+" %-10s %s\n", key, displaySetting(value), note)
+	}
+	fmt.Println(colorYellow + "└──────────────────────────────────────────────────────────┘" + colorReset)
+	if configPath != "" {
+		fmt.Println(colorDim + "  Saved in " + configPath + colorReset)
+	}
+}
+
+// displaySetting shows empty settings as "none"
+func displaySetting(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,202 @@
+/*
+Axion CLI Calculator - Non-Interactive Evaluation
+=================================================
+
+This file implements one-shot evaluation for scripts and shell pipelines:
+
+    axion eval "2 + 2" "sqrt(16)"     # one result per expression
+    axion "5 km + 300 m in mi"         # bare expressions work too
+    axion eval --precision 3 --display sci -- "-1/3"
+
+Only results are printed, without banner, prompt or colors; errors, and the
+date of the rate table behind a currency result, go to stderr. Expressions are evaluated in order and share variables. The exit
+status tells scripts what went wrong:
+
+    0  success                 3  syntax error
+    1  other failure (I/O)     4  evaluation error (domain, undefined name)
+    2  usage error             5  unit error (unknown unit, dimension mismatch)
+
+--display, --precision and --angle apply as usual (--format is still
+accepted as an older name of --display).
+Expressions starting with '-' must follow "--" so they are not read as flags.
+*/
+
+package cmd
+
+import (
+	"Axion/evaluator"
+	"Axion/parser"
+	"Axion/tokenizer"
+	"Axion/units"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Exit codes by error class
+const (
+	exitError  = 1
+	exitUsage  = 2
+	exitSyntax = 3
+	exitEval   = 4
+	exitUnit   = 5
+)
+
+// errorKinds names the error classes by exit code
+var errorKinds = map[int]string{
+	exitError:  "error",
+	exitUsage:  "usage",
+	exitSyntax: "syntax",
+	exitEval:   "eval",
+	exitUnit:   "unit",
+}
+
+// ExitError is an error carrying the exit status of the command that failed
+type ExitError struct {
+	Code int
+	Err  error
+	Span *tokenizer.Span // Location of a syntax error in the input, if known
+}
+
+// Error returns the message of the underlying error
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Kind names the class of the error, e.g. "syntax" or "unit"
+func (e *ExitError) Kind() string {
+	return errorKinds[e.Code]
+}
+
+// ExitCode returns the process exit status for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return exitUsage // flag and argument errors reported by cobra
+}
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <expression>...",
+	Short: "Evaluate expressions and print only the results",
+	Example: `  axion eval "2 + 2"
+  axion eval "r = 3" "pi * r^2" --precision 4
+  axion eval --display eng "60 mi / 2 h in km/h"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return evalArgs(args)
+	},
+}
+
+// normalizeFlags accepts --format, the older name of --display, so that
+// existing scripts keep working; help and examples only show --display
+func normalizeFlags(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "format" {
+		name = "display"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// evalArgs evaluates each argument as an expression, printing the results
+// and stopping at the first error
+func evalArgs(args []string) error {
+	for _, input := range args {
+		result, err := evaluate(input)
+		if err != nil {
+			if structured() {
+				writeRecord(errorRecord(input, err))
+				return &ExitError{Code: ExitCode(err), Err: errReported}
+			}
+			return err
+		}
+		evaluator.Record(result)
+		emitResult(input, result)
+	}
+	return nil
+}
+
+// evaluate tokenizes, parses and evaluates input, classifying any error
+func evaluate(input string) (units.Quantity, error) {
+	ast, err := parse(input)
+	if err != nil {
+		return units.Quantity{}, err
+	}
+	return evalNode(ast)
+}
+
+// parse tokenizes and parses input, reporting errors as syntax errors with
+// the span of the offending input
+func parse(input string) (*parser.Node, error) {
+	tokens, spans, err := tokenizer.Scan(input)
+	if err != nil {
+		syntaxErr := &ExitError{Code: exitSyntax, Err: err}
+		var tokErr *tokenizer.Error
+		if errors.As(err, &tokErr) {
+			syntaxErr.Span = &tokErr.Span
+		}
+		return nil, syntaxErr
+	}
+	p := parser.Parser{Tokens: tokens}
+	ast, err := p.ParseExpression()
+	if err != nil {
+		// The parser stops at the offending token, or after the last one
+		end := len([]rune(input))
+		span := tokenizer.Span{Start: end, End: end}
+		if pos := p.Pos(); pos < len(spans) {
+			span = spans[pos]
+		}
+		return nil, &ExitError{Code: exitSyntax, Err: err, Span: &span}
+	}
+	return ast, nil
+}
+
+// evalNode evaluates a parsed expression, classifying any error
+func evalNode(ast *parser.Node) (units.Quantity, error) {
+	evaluator.LastSimulation = nil
+	result, err := evaluator.EvalQuantity(ast)
+	if err != nil {
+		return units.Quantity{}, classify(err)
+	}
+	return result, nil
+}
+
+// classify wraps an evaluation or conversion error as a unit or eval error
+func classify(err error) error {
+	var unitErr *units.UnitError
+	if errors.As(err, &unitErr) {
+		return &ExitError{Code: exitUnit, Err: err}
+	}
+	return &ExitError{Code: exitEval, Err: err}
+}
+
+// plainQuantity formats a result and its unit without colors
+func plainQuantity(q units.Quantity) string {
+	value, unit := q.Display()
+	text, unit := plainValueUnit(value, unit)
+	return strings.TrimSpace(text + " " + unit)
+}
+
+// reportError prints an error returned by Execute to stderr
+func reportError(err error) {
+	if errors.Is(err, errReported) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "error: "+err.Error())
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		fmt.Fprintln(os.Stderr, "Run 'axion --help' for usage.")
+	}
+}
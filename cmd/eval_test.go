@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"Axion/tokenizer"
+	"Axion/units"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"success", nil, 0},
+		{"exit error", &ExitError{Code: exitUnit, Err: errors.New("bad unit")}, exitUnit},
+		{"wrapped exit error", fmt.Errorf("context: %w", &ExitError{Code: exitSyntax, Err: errReported}), exitSyntax},
+		{"cobra usage error", errors.New("unknown flag: --bogus"), exitUsage},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		err      error
+		expected int
+		kind     string
+	}{
+		{"unit error", units.Errorf("unknown unit xyz"), exitUnit, "unit"},
+		{"wrapped unit error", fmt.Errorf("variable d: %w", units.Errorf("bad dimension")), exitUnit, "unit"},
+		{"evaluation error", errors.New("division by zero"), exitEval, "eval"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err)
+			assert.Equal(t, tt.expected, ExitCode(err))
+			var exitErr *ExitError
+			if assert.ErrorAs(t, err, &exitErr) {
+				assert.Equal(t, tt.kind, exitErr.Kind())
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEvaluate_ErrorClasses(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		code  int
+		span  *tokenizer.Span
+	}{
+		{"2 +", exitSyntax, &tokenizer.Span{Start: 3, End: 3}},
+		{"2 $ 3", exitSyntax, &tokenizer.Span{Start: 2, End: 3}},
+		{"1 / 0", exitEval, nil},
+		{"nope + 1", exitEval, nil},
+		{"sqrt(-1)", exitEval, nil},
+		{"5 km + 2 s", exitUnit, nil},
+		{"sin(3 m)", exitUnit, nil},
+		{"5 m in xyz", exitUnit, nil},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			resetSession(t)
+			_, err := evaluate(tt.input)
+			assert.Equal(t, tt.code, ExitCode(err))
+			var exitErr *ExitError
+			if assert.ErrorAs(t, err, &exitErr) {
+				assert.Equal(t, tt.span, exitErr.Span)
+			}
+		})
+	}
+}
+
+func TestEvalArgs(t *testing.T) {
+	resetSession(t)
+	var err error
+	out := captureStdout(t, func() {
+		err = evalArgs([]string{"r = 3", "r * 2", "5 km in m"})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n6\n5000 m\n", out)
+
+	out = captureStdout(t, func() {
+		err = evalArgs([]string{"1 + 1", "5 km + 2 s", "3"})
+	})
+	assert.Equal(t, exitUnit, ExitCode(err))
+	assert.Equal(t, "2", strings.TrimSpace(out))
+}
+
+func TestEvalArgs_Currency(t *testing.T) {
+	resetSession(t)
+	require.NoError(t, units.SetRates(units.Rates{Base: "USD", Date: "2020-01-02", Rates: map[string]float64{"EUR": 0.9}}))
+
+	var out string
+	errOut := captureStderr(t, func() {
+		out = captureStdout(t, func() {
+			assert.NoError(t, evalArgs([]string{"100 USD in EUR"}))
+		})
+	})
+	assert.Equal(t, "90 EUR\n", out)
+	assert.Contains(t, errOut, "Rates as of 2020-01-02 (base USD)")
+	assert.Contains(t, errOut, "Warning: currency rates are")
+}
@@ -0,0 +1,194 @@
+/*
+Axion CLI Calculator - Syntax Highlighting
+==========================================
+
+This file colors REPL input as it is typed, using the tokenizer to classify
+each part of the line:
+
+    numbers            yellow       functions          blue
+    variables, consts  green        units              cyan
+    ans, $3, @h12      green
+    operators, in, to  purple       unknown names      red
+
+The parenthesis under or just before the cursor is shown in bold together
+with its partner. Parentheses without a partner and characters the
+tokenizer rejects (a lone '&', '$', a second decimal point) are shown in bold
+red, so mistakes are visible before Enter is pressed. The mono theme turns
+highlighting off along with the other colors.
+*/
+
+package cmd
+
+import (
+	"Axion/constants"
+	"Axion/evaluator"
+	"Axion/tokenizer"
+	"Axion/units"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// keywords are identifiers with a meaning of their own in expressions
+var keywords = map[string]bool{"in": true, "to": true, "const": true, "force": true}
+
+// highlightInput returns line with ANSI colors; pos is the cursor, or -1
+// when the line has been entered
+func highlightInput(line []rune, pos int) string {
+	styles := make([]string, len(line))
+
+	// REPL commands: only convert takes an expression-like argument
+	start := 0
+	for start < len(line) && unicode.IsSpace(line[start]) {
+		start++
+	}
+	end := start
+	for end < len(line) && !unicode.IsSpace(line[end]) {
+		end++
+	}
+	if word := string(line[start:end]); containsField(replCommands, word) {
+		for i := start; i < end; i++ {
+			styles[i] = colorPurple
+		}
+		if word != "convert" {
+			return applyStyles(line, styles)
+		}
+		start = end
+	}
+
+	parens := styleExpression(line, start, styles)
+	matchParens(line, parens, pos, styles)
+	return applyStyles(line, styles)
+}
+
+// styleExpression colors the tokens of line[from:], marking input the
+// tokenizer rejects and scanning on after it; it returns the positions of
+// the parentheses found
+func styleExpression(line []rune, from int, styles []string) []int {
+	var parens []int
+	for from < len(line) {
+		text := line[from:]
+		tokens, spans, err := tokenizer.Scan(string(text))
+		next := len(line)
+
+		var tokErr *tokenizer.Error
+		if errors.As(err, &tokErr) {
+			// Color what precedes the error, then continue after it
+			tokens, spans, _ = tokenizer.Scan(string(text[:tokErr.Span.Start]))
+			for i := tokErr.Span.Start; i < tokErr.Span.End; i++ {
+				styles[from+i] = colorBold + colorRed
+			}
+			next = from + tokErr.Span.End
+		}
+
+		for i, tok := range tokens {
+			span := spans[i]
+			if tok.Type == tokenizer.PAREN {
+				parens = append(parens, from+span.Start)
+				continue
+			}
+			style := tokenStyle(tokens, i)
+			for j := span.Start; j < span.End; j++ {
+				styles[from+j] = style
+			}
+		}
+		from = next
+	}
+	return parens
+}
+
+// tokenStyle returns the color of tokens[i]
+func tokenStyle(tokens []tokenizer.Token, i int) string {
+	tok := tokens[i]
+	switch tok.Type {
+	case tokenizer.NUMBER:
+		return colorYellow
+	case tokenizer.FUNCTION:
+		if tok.Value == "!" {
+			return colorPurple
+		}
+		return colorBlue
+	case tokenizer.IDENT:
+		return identStyle(tokens, i)
+	}
+	return colorPurple
+}
+
+// identStyle colors a name the way the evaluator would resolve it
+func identStyle(tokens []tokenizer.Token, i int) string {
+	name := tokens[i].Value
+	afterNumber := i > 0 && tokens[i-1].Implicit
+	assigned := i+1 < len(tokens) && tokens[i+1].Type == tokenizer.ASSIGN
+	_, isVar := evaluator.Vars[name]
+	_, isConst := constants.Get(name)
+
+	switch {
+	case evaluator.IsReference(name):
+		return colorGreen
+	case keywords[name] && !isVar:
+		return colorPurple
+	case isVar || assigned:
+		return colorGreen
+	case afterNumber && units.IsUnit(name):
+		return colorCyan // 2 h is hours, not the Planck constant
+	case isConst:
+		return colorGreen
+	case units.IsUnit(name):
+		return colorCyan
+	}
+	return colorRed
+}
+
+// matchParens pairs the parentheses at the given positions, marking those
+// without a partner and the pair at the cursor
+func matchParens(line []rune, parens []int, pos int, styles []string) {
+	partner := make(map[int]int)
+	var open []int
+	for _, p := range parens {
+		if line[p] == '(' {
+			open = append(open, p)
+			continue
+		}
+		if len(open) == 0 {
+			styles[p] = colorBold + colorRed
+			continue
+		}
+		o := open[len(open)-1]
+		open = open[:len(open)-1]
+		partner[o], partner[p] = p, o
+	}
+	for _, o := range open {
+		styles[o] = colorBold + colorRed
+	}
+
+	if pos < 0 {
+		return
+	}
+	for _, at := range []int{pos, pos - 1} {
+		if other, ok := partner[at]; ok {
+			styles[at] = colorBold + colorGreen
+			styles[other] = colorBold + colorGreen
+			return
+		}
+	}
+}
+
+// applyStyles writes line with each rune in its style
+func applyStyles(line []rune, styles []string) string {
+	var out strings.Builder
+	current := ""
+	for i, r := range line {
+		if styles[i] != current {
+			if current != "" {
+				out.WriteString(colorReset)
+			}
+			out.WriteString(styles[i])
+			current = styles[i]
+		}
+		out.WriteRune(r)
+	}
+	if current != "" {
+		out.WriteString(colorReset)
+	}
+	return out.String()
+}
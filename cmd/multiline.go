@@ -0,0 +1,141 @@
+/*
+Axion CLI Calculator - Multi-line Input
+=======================================
+
+This file lets one input span several lines, in the REPL and in scripts.
+A line continues on the next one when it
+
+    ends with '\'                     total = 2 + \
+    leaves a parenthesis open         mean(1, 2,
+    ends with an operator or ','      x = 3 *
+
+The REPL then shows the "... " continuation prompt until the input is
+complete; Ctrl-C discards the whole input and Ctrl-D evaluates what has been
+typed so far. The continued lines are joined with single spaces, so the
+input is recalled from history as one line.
+
+Blocks of statements are accepted too: a multi-line paste into the REPL, or
+the lines typed after the paste command up to a lone "end", run one
+statement after another just like a script.
+*/
+
+package cmd
+
+import (
+	"Axion/lineedit"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// continuationOperators are the characters that cannot end an input
+const continuationOperators = "+-*/^=<>&|,"
+
+// continues reports whether text is incomplete and goes on on the next line
+func continues(text string) bool {
+	text = strings.TrimSpace(stripComment(text))
+	if text == "" {
+		return false
+	}
+	if strings.HasSuffix(text, "\\") {
+		return true
+	}
+
+	depth := 0
+	for _, ch := range text {
+		switch ch {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		}
+	}
+	if depth > 0 {
+		return true
+	}
+	return strings.ContainsRune(continuationOperators, rune(text[len(text)-1]))
+}
+
+// joinLines appends the next line to an incomplete input, dropping the
+// input's comment and trailing '\'
+func joinLines(text, next string) string {
+	text = strings.TrimSpace(stripComment(text))
+	text = strings.TrimSpace(strings.TrimSuffix(text, "\\"))
+	if text == "" {
+		return strings.TrimSpace(next)
+	}
+	return text + " " + strings.TrimSpace(next)
+}
+
+// stripComment removes a '#' comment from line
+func stripComment(line string) string {
+	if i := strings.IndexRune(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// continuationPrompt returns the prompt shown while an input is incomplete
+func continuationPrompt() string {
+	if structured() {
+		return ""
+	}
+	return colorDim + "... " + colorReset
+}
+
+// readContinued reads continuation lines until line is a complete input;
+// it returns lineedit.ErrInterrupt if the input is discarded with Ctrl-C
+func readContinued(editor *lineedit.Editor, line string) (string, error) {
+	input := strings.TrimSpace(line)
+	for continues(input) {
+		next, err := editor.ReadLine(continuationPrompt())
+		if err == io.EOF {
+			break // Evaluate what has been typed
+		}
+		if err != nil {
+			return "", err
+		}
+		input = joinLines(input, next)
+	}
+	return input, nil
+}
+
+// readPaste reads a block of lines up to a lone "end" or the end of input
+func readPaste(editor *lineedit.Editor) ([]string, error) {
+	if !structured() {
+		fmt.Println(colorDim + "Paste or type statements, then 'end' on a line of its own (Ctrl-C cancels)" + colorReset)
+	}
+	var lines []string
+	for {
+		line, err := editor.ReadLine(continuationPrompt())
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "end" {
+			return lines, nil
+		}
+		lines = append(lines, strings.Split(line, "\n")...)
+	}
+}
+
+// runBlock runs the statements of a block of lines one after another; it
+// reports false if one of them quits the REPL
+func runBlock(lines []string) bool {
+	pending := ""
+	for i, line := range lines {
+		pending = joinLines(pending, line)
+		if continues(pending) && i < len(lines)-1 {
+			continue
+		}
+		for _, statement := range splitStatements(pending) {
+			if !handleInput(statement) {
+				return false
+			}
+		}
+		pending = ""
+	}
+	return true
+}
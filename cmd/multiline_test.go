@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinues(t *testing.T) {
+	for _, tt := range []struct {
+		text     string
+		expected bool
+	}{
+		{"2 + 2", false},
+		{"", false},
+		{"total = 2 + \\", true},
+		{"mean(1, 2,", true},
+		{"max(min(1, 2)", true},
+		{"sum(1, 2)", false},
+		{"x = 3 *", true},
+		{"x =", true},
+		{"1 <", true},
+		{"a &&", true},
+		{"2 ^", true},
+		{"5 km + 300 m", false},
+		{"x = 3 * # comment", true},
+		{"x = 3 # ends with an operator +", false},
+		{"2)", false},
+	} {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, continues(tt.text))
+		})
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	for _, tt := range []struct {
+		text     string
+		next     string
+		expected string
+	}{
+		{"", "2 + 2", "2 + 2"},
+		{"", "  x = 1  ", "x = 1"},
+		{"total = 2 + \\", "3", "total = 2 + 3"},
+		{"mean(1, 2,", "     3, 4)", "mean(1, 2, 3, 4)"},
+		{"x = 3 *   # width", "4", "x = 3 * 4"},
+		{"  \\", "5", "5"},
+	} {
+		t.Run(tt.text+"|"+tt.next, func(t *testing.T) {
+			assert.Equal(t, tt.expected, joinLines(tt.text, tt.next))
+		})
+	}
+}
+
+func TestRunBlock(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		lines    []string
+		expected string
+		running  bool
+	}{
+		{"one statement per line", []string{"x = 2", "x * 3"}, "[1]: 2\n[2]: 6\n", true},
+		{"continued statement", []string{"mean(1, 2,", "3, 4)"}, "[1]: 2.5\n", true},
+		{"unfinished last line", []string{"2 +"}, "Error: unexpected end of expression\n", true},
+		{"statements on one line", []string{"a = 1; a + 1"}, "[1]: 1\n[2]: 2\n", true},
+		{"exit stops the block", []string{"1", "exit", "2"}, "[1]: 1\nGoodbye!\n", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSession(t)
+			applyTheme("mono")
+			var running bool
+			out := captureStdout(t, func() { running = runBlock(tt.lines) })
+			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, tt.running, running)
+		})
+	}
+}
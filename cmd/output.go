@@ -0,0 +1,167 @@
+/*
+Axion CLI Calculator - Structured Output
+========================================
+
+This file implements the --output flag for tools that wrap Axion. In the
+default text mode results are written for people; with json, csv or tsv
+every evaluation, conversion, variable (vars) and history entry becomes one
+record instead, and the REPL drops its banner and prompt:
+
+    $ axion --output json "5 km in mi" "2 +"
+    {"expression":"5 km in mi","value":3.106863683249034,"unit":"mi","formatted":"3.10686 mi"}
+    {"expression":"2 +","kind":"syntax","error":"unexpected end of expression","span":{"start":3,"end":3}}
+
+JSON output is one object per line. CSV and TSV output starts with a header
+row (expression, value, unit, formatted, kind, error, start, end). Values
+keep full precision; infinities and NaN are written as "+∞", "-∞" and "NaN"
+as in history.json. For vars the expression is the variable name. Spans are
+rune offsets into the expression and are known for syntax errors only.
+*/
+
+package cmd
+
+import (
+	"Axion/history"
+	"Axion/tokenizer"
+	"Axion/units"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// outputFormat is the format results are written in
+var outputFormat = "text"
+
+// outputFormats lists the values accepted by --output
+var outputFormats = []string{"text", "json", "csv", "tsv"}
+
+// csvHeaderWritten records whether the CSV/TSV header row was written
+var csvHeaderWritten bool
+
+// record is one structured result or error
+type record struct {
+	Expression string             `json:"expression"`
+	Value      *history.JsonFloat `json:"value,omitempty"`
+	Unit       string             `json:"unit,omitempty"`
+	Formatted  string             `json:"formatted,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Span       *tokenizer.Span    `json:"span,omitempty"`
+}
+
+// registerOutputFlag adds the --output flag to cmd
+func registerOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputFormat,
+		"output format ("+strings.Join(outputFormats, ", ")+")")
+}
+
+// checkOutputFormat validates the --output flag
+func checkOutputFormat() error {
+	outputFormat = strings.ToLower(outputFormat)
+	for _, format := range outputFormats {
+		if outputFormat == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("--output must be one of %s, got %q", strings.Join(outputFormats, ", "), outputFormat)
+}
+
+// structured reports whether results are written as records
+func structured() bool {
+	return outputFormat != "text"
+}
+
+// valueRecord describes value in unit as the result of expression
+func valueRecord(expression string, value float64, unit string) record {
+	v := history.JsonFloat(value)
+	return record{
+		Expression: expression,
+		Value:      &v,
+		Unit:       unit,
+		Formatted:  plainWithUnit(value, unit),
+	}
+}
+
+// quantityRecord describes a result in its display unit
+func quantityRecord(expression string, q units.Quantity) record {
+	value, unit := q.Display()
+	return valueRecord(expression, value, unit)
+}
+
+// errorRecord describes a failed expression
+func errorRecord(expression string, err error) record {
+	r := record{Expression: expression, Kind: errorKinds[exitError], Error: err.Error()}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		r.Kind = exitErr.Kind()
+		r.Span = exitErr.Span
+	}
+	return r
+}
+
+// writeRecord writes r to stdout in the output format
+func writeRecord(r record) {
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(r)
+		return
+	}
+
+	writer := csv.NewWriter(os.Stdout)
+	if outputFormat == "tsv" {
+		writer.Comma = '\t'
+	}
+	if !csvHeaderWritten {
+		writer.Write([]string{"expression", "value", "unit", "formatted", "kind", "error", "start", "end"})
+		csvHeaderWritten = true
+	}
+	value, start, end := "", "", ""
+	if r.Value != nil {
+		data, _ := r.Value.MarshalJSON()
+		value = strings.Trim(string(data), `"`)
+	}
+	if r.Span != nil {
+		start, end = strconv.Itoa(r.Span.Start), strconv.Itoa(r.Span.End)
+	}
+	writer.Write([]string{r.Expression, value, r.Unit, r.Formatted, r.Kind, r.Error, start, end})
+	writer.Flush()
+}
+
+// emitResult writes the result of expression as text or as a record; a
+// currency result is followed by the rate date on stderr (noteRates)
+func emitResult(expression string, q units.Quantity) {
+	if structured() {
+		writeRecord(quantityRecord(expression, q))
+	} else {
+		fmt.Println(plainQuantity(q))
+	}
+	if isCurrency(q.Dim) {
+		noteRates()
+	}
+}
+
+// writeVariables writes every variable as a record named by the expression
+func writeVariables(names []string, values map[string]units.Quantity) {
+	for _, name := range names {
+		writeRecord(quantityRecord(name, values[name]))
+	}
+}
+
+// writeHistory writes the stored history as records, oldest first
+func writeHistory() error {
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		writeRecord(valueRecord(entry.Expression, float64(entry.Result), entry.Unit))
+	}
+	return nil
+}
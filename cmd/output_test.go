@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"Axion/history"
+	"Axion/tokenizer"
+	"Axion/units"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRecord(t *testing.T) {
+	km, _ := units.Lookup("km")
+	distance, _ := km.Reading(units.Scalar(5))
+	records := []record{
+		quantityRecord("5 km", distance),
+		valueRecord("1/0", math.Inf(1), ""),
+		valueRecord("a, b", 1.5, ""),
+		errorRecord("2 +", &ExitError{Code: exitSyntax, Err: errors.New("unexpected end of expression"), Span: &tokenizer.Span{Start: 3, End: 3}}),
+		errorRecord("5 km + 2 s", classify(units.Errorf("incompatible units"))),
+	}
+
+	for _, tt := range []struct {
+		format   string
+		expected string
+	}{
+		{"json", `{"expression":"5 km","value":5,"unit":"km","formatted":"5 km"}
+{"expression":"1/0","value":"+∞","formatted":"+∞"}
+{"expression":"a, b","value":1.5,"formatted":"1.5"}
+{"expression":"2 +","kind":"syntax","error":"unexpected end of expression","span":{"start":3,"end":3}}
+{"expression":"5 km + 2 s","kind":"unit","error":"incompatible units"}
+`},
+		{"csv", `expression,value,unit,formatted,kind,error,start,end
+5 km,5,km,5 km,,,,
+1/0,+∞,,+∞,,,,
+"a, b",1.5,,1.5,,,,
+2 +,,,,syntax,unexpected end of expression,3,3
+5 km + 2 s,,,,unit,incompatible units,,
+`},
+		{"tsv", "expression\tvalue\tunit\tformatted\tkind\terror\tstart\tend\n" +
+			"5 km\t5\tkm\t5 km\t\t\t\t\n" +
+			"1/0\t+∞\t\t+∞\t\t\t\t\n" +
+			"a, b\t1.5\t\t1.5\t\t\t\t\n" +
+			"2 +\t\t\t\tsyntax\tunexpected end of expression\t3\t3\n" +
+			"5 km + 2 s\t\t\t\tunit\tincompatible units\t\t\n"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			resetSession(t)
+			outputFormat = tt.format
+			out := captureStdout(t, func() {
+				for _, r := range records {
+					writeRecord(r)
+				}
+			})
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	t.Cleanup(func() { outputFormat = "text" })
+	for _, format := range []string{"text", "json", "CSV", "tsv"} {
+		outputFormat = format
+		assert.NoError(t, checkOutputFormat(), format)
+	}
+	outputFormat = "xml"
+	assert.ErrorContains(t, checkOutputFormat(), "--output must be one of")
+}
+
+func TestWriteHistory(t *testing.T) {
+	resetSession(t)
+	outputFormat = "json"
+	assert.NoError(t, history.AddHistory("20 C", 20, "C"))
+	assert.NoError(t, history.AddHistory("60 mi / 2 h in km/h", 48.2802, "km/h"))
+	assert.NoError(t, history.AddHistory("2 + 2", 4, ""))
+
+	out := captureStdout(t, func() {
+		assert.NoError(t, writeHistory())
+	})
+	assert.Equal(t, `{"expression":"20 C","value":20,"unit":"C","formatted":"20 C"}
+{"expression":"60 mi / 2 h in km/h","value":48.2802,"unit":"km/h","formatted":"48.2802 km/h"}
+{"expression":"2 + 2","value":4,"formatted":"4"}
+`, out)
+}
+
+func TestHandleExpression_Structured(t *testing.T) {
+	resetSession(t)
+	outputFormat = "csv"
+	out := captureStdout(t, func() {
+		handleInput("x = 2 km")
+		handleInput("x / 0")
+		handleInput("vars")
+	})
+	assert.Equal(t, `expression,value,unit,formatted,kind,error,start,end
+x = 2 km,2,km,2 km,,,,
+x / 0,,,,eval,division by zero,,
+x,2,km,2 km,,,,
+`, out)
+}
@@ -0,0 +1,198 @@
+/*
+Currency Rates Commands
+=======================
+Part of Axion CLI Calculator
+
+This file implements the `axion rates` subcommands and the matching REPL
+commands for the offline currency rate table. Imported tables are stored as
+rates.json in the axion data directory and loaded at startup.
+*/
+
+package cmd
+
+import (
+	"Axion/paths"
+	"Axion/settings"
+	"Axion/units"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var ratesCmd = &cobra.Command{
+	Use:   "rates",
+	Short: "Show the active currency rate table",
+	Run: func(cmd *cobra.Command, args []string) {
+		plainWhenPiped()
+		showRates()
+	},
+}
+
+var ratesImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a JSON or CSV currency rate table",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plainWhenPiped()
+		if err := importRates(args[0]); err != nil {
+			return &ExitError{Code: exitError, Err: err}
+		}
+		return nil
+	},
+}
+
+// plainWhenPiped turns colors off when stdout is not a terminal, so that a
+// rate table written to a file or pipe carries no escape sequences
+func plainWhenPiped() {
+	if !stdoutIsTerminal() {
+		plainOutput = true
+		applyTheme("mono")
+	}
+}
+
+// loadRates activates the rate table stored in file
+func loadRates(file string) error {
+	r, err := units.ReadRates(file)
+	if err != nil {
+		return err
+	}
+	return units.SetRates(r)
+}
+
+// importRates validates a rate table, activates it and stores it for later sessions
+func importRates(file string) error {
+	r, err := units.ReadRates(file)
+	if err != nil {
+		return err
+	}
+	if err := units.SetRates(r); err != nil {
+		return err
+	}
+
+	target := paths.Data("rates.json")
+	if target == "" {
+		return fmt.Errorf("no data directory available to store rates")
+	}
+	if err := units.SaveRates(target, r); err != nil {
+		return fmt.Errorf("failed to save rates: %w", err)
+	}
+
+	fmt.Printf(colorGreen+"Imported %d rates (base %s, dated %s)\n"+colorReset, len(r.Rates), r.Base, r.Date)
+	if notice := staleRatesWarning(); notice != "" {
+		fmt.Println(notice)
+	}
+	return nil
+}
+
+// handleRates processes the REPL rates commands
+func handleRates(input string) {
+	parts := strings.Fields(input)
+	switch {
+	case len(parts) == 1:
+		showRates()
+
+	case len(parts) == 3 && parts[1] == "import":
+		if err := importRates(parts[2]); err != nil {
+			fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
+		}
+
+	case len(parts) == 3 && parts[1] == "maxage":
+		if _, err := strconv.Atoi(parts[2]); err != nil {
+			fmt.Printf(colorRed+"Invalid number of days: %s\n"+colorReset, parts[2])
+			return
+		}
+		if err := changeSetting("rates.max_age", parts[2]); err != nil {
+			fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
+			return
+		}
+		fmt.Printf(colorGreen+"Rates older than %d days will be flagged\n"+colorReset, settings.RatesMaxAge)
+
+	default:
+		fmt.Println(colorRed + "Usage: " + colorReset + "rates | rates import <file> | rates maxage <days>")
+	}
+}
+
+// showRates displays the active currency rate table
+func showRates() {
+	r := units.CurrentRates()
+	if r == nil {
+		fmt.Println(colorYellow + "No currency rates loaded." + colorReset)
+		fmt.Println(colorDim + "   Import with: axion rates import <file.json|file.csv>" + colorReset)
+		return
+	}
+
+	fmt.Println(colorGreen + "┌─ Currency Rates ─────────────────────────────────────────┐" + colorReset)
+	fmt.Printf("│ %-25s %s\n", colorBold+"Base:"+colorReset, r.Base)
+	fmt.Printf("│ %-25s %s\n", colorBold+"Date:"+colorReset, r.Date)
+	for _, code := range r.Codes() {
+		if code != r.Base {
+			fmt.Printf("│ %-25s %s\n", colorCyan+code+colorReset, formatResult(r.Rates[code]))
+		}
+	}
+	fmt.Println(colorGreen + "└──────────────────────────────────────────────────────────┘" + colorReset)
+	if notice := staleRatesWarning(); notice != "" {
+		fmt.Println(notice)
+	}
+}
+
+// ratesNotice describes the rate table used by a currency result
+func ratesNotice() string {
+	r := units.CurrentRates()
+	if r == nil {
+		return ""
+	}
+	notice := colorDim + ratesDate(r) + colorReset
+	if warning := staleRatesWarning(); warning != "" {
+		notice += "\n" + warning
+	}
+	return notice
+}
+
+// noteRates writes the date of the rate table behind a currency result of
+// eval or a script to stderr, warning if the rates are stale, so that
+// stdout holds only results
+func noteRates() {
+	r := units.CurrentRates()
+	if r == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, ratesDate(r))
+	if message := staleRates(); message != "" {
+		warn("%s", message)
+	}
+}
+
+// ratesDate names the date and base currency of a rate table
+func ratesDate(r *units.Rates) string {
+	return fmt.Sprintf("Rates as of %s (base %s)", r.Date, r.Base)
+}
+
+// staleRatesWarning returns a warning if the rates exceed settings.RatesMaxAge
+func staleRatesWarning() string {
+	if message := staleRates(); message != "" {
+		return colorYellow + "Warning: " + message + colorReset
+	}
+	return ""
+}
+
+// staleRates describes how far the rates exceed settings.RatesMaxAge, or
+// returns "" while they are recent enough
+func staleRates() string {
+	r := units.CurrentRates()
+	maxAge := time.Duration(settings.RatesMaxAge) * 24 * time.Hour
+	if r == nil || !r.Stale(time.Now(), maxAge) {
+		return ""
+	}
+	days := int(time.Since(r.Time()).Hours() / 24)
+	return fmt.Sprintf("currency rates are %d days old (limit %d); import newer rates with 'axion rates import <file>'",
+		days, settings.RatesMaxAge)
+}
+
+// isCurrency reports whether a unit dimension involves currency
+func isCurrency(dim units.Dimension) bool {
+	return dim[units.Currency] != 0
+}
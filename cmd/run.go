@@ -0,0 +1,285 @@
+/*
+Axion CLI Calculator - Script Files and Batch Mode
+==================================================
+
+This file runs Axion scripts, either from a file (`axion run calc.ax`) or
+from standard input when it is not a terminal (`axion < calc.ax`,
+`echo "2+2" | axion`). Scripts contain one expression per line:
+
+    # Circle area
+    r = 3 m; area = pi * r^2    # several statements separated by ';'
+    print(area, r)
+    area in cm^2
+    mean(1, 2,
+         3, 4)                  # continued while a parenthesis is open
+
+Comments start with '#'. Variables persist from line to line (Axion has
+no user-defined functions to persist). Only the arguments of top-level
+print(...) calls and the result of the final statement (unless it is an
+assignment) are written to stdout, without banner, prompt or colors.
+
+Statements starting with a REPL command (convert, vars, history, set,
+precision, units, ...) run as in the REPL and print their output as they
+go; exit or quit ends the script. clear and cls do nothing in a script
+and help is written to stderr, so neither puts terminal output on stdout.
+Command errors are reported as in the REPL and do not stop the script.
+Errors are written to stderr as "<file>:<line>: error: <message>", with
+the first line of a continued input.
+
+By default the first error stops the script (--fail-fast) and its class
+sets the exit status, as for axion eval. With --continue the remaining
+lines still run and the exit status reflects the first error.
+*/
+
+package cmd
+
+import (
+	"Axion/evaluator"
+	"Axion/parser"
+	"Axion/units"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// continueOnError selects the --continue error policy for scripts
+var continueOnError bool
+
+// interactive forces the REPL even when stdin is not a terminal
+var interactive bool
+
+var runCmd = &cobra.Command{
+	Use:   "run <file>",
+	Short: "Run a script of expressions",
+	Example: `  axion run budget.ax
+  axion run --continue checks.ax
+  axion < budget.ax`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return &ExitError{Code: exitError, Err: err}
+		}
+		defer f.Close()
+		return runScript(f, args[0])
+	},
+}
+
+// registerRunFlags adds the script error policy flags to cmd
+func registerRunFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.BoolVar(&continueOnError, "continue", false, "keep running a script after an error")
+	flags.Bool("fail-fast", true, "stop a script at the first error (default)")
+	cmd.MarkFlagsMutuallyExclusive("continue", "fail-fast")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "start the REPL even when stdin is not a terminal")
+}
+
+// stdinIsTerminal reports whether standard input is an interactive terminal
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stdoutIsTerminal reports whether standard output is an interactive terminal
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runScript evaluates every statement read from r; name labels error messages
+func runScript(r io.Reader, name string) error {
+	plainOutput = true
+	applyTheme("mono")
+	scanner := bufio.NewScanner(r)
+	var firstErr error
+	var quit bool             // exit or quit has ended the script
+	var final *units.Quantity // result of the last statement, if any
+	var finalStatement string
+
+	// runInput runs the statements of one complete input starting at line
+	runInput := func(input string, line int) error {
+		for _, statement := range splitStatements(input) {
+			if isCommand(statement) {
+				switch statement {
+				case "exit", "quit":
+					quit = true
+					return nil
+				case "clear", "cls":
+					continue // no screen to clear
+				case "help":
+					toStderr(printHelp)
+					continue
+				}
+				handleInput(statement)
+				final = nil
+				continue
+			}
+			result, err := runStatement(statement)
+			if err != nil {
+				if structured() {
+					writeRecord(errorRecord(statement, err))
+				} else {
+					fmt.Fprintf(os.Stderr, "%s:%d: error: %v\n", name, line, err)
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+				if !continueOnError {
+					return &ExitError{Code: ExitCode(err), Err: errReported}
+				}
+				final = nil
+				continue
+			}
+			final, finalStatement = result, statement
+		}
+		return nil
+	}
+
+	pending, start := "", 0
+	for line := 1; scanner.Scan(); line++ {
+		if pending == "" {
+			start = line
+		}
+		pending = joinLines(pending, scanner.Text())
+		if continues(pending) {
+			continue
+		}
+		if err := runInput(pending, start); err != nil {
+			return err
+		}
+		pending = ""
+		if quit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &ExitError{Code: exitError, Err: err}
+	}
+	if pending != "" {
+		// The script ends inside a continued input
+		if err := runInput(pending, start); err != nil {
+			return err
+		}
+	}
+
+	if final != nil {
+		emitResult(finalStatement, *final)
+	}
+	if firstErr != nil {
+		return &ExitError{Code: ExitCode(firstErr), Err: errReported}
+	}
+	return nil
+}
+
+// toStderr runs f with stdout redirected to stderr
+func toStderr(f func()) {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+	f()
+}
+
+// errReported marks script errors already printed with their line number
+var errReported = errors.New("script failed")
+
+// runStatement evaluates one statement, printing print(...) arguments
+// immediately; it returns the result if the statement is a bare expression
+// that could end the script
+func runStatement(statement string) (*units.Quantity, error) {
+	ast, err := parse(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ast.Type == parser.NODE_FUNCTION && ast.Value == "print":
+		if len(ast.Children) == 0 {
+			return nil, &ExitError{Code: exitEval, Err: fmt.Errorf("print requires at least one argument")}
+		}
+		values := make([]units.Quantity, len(ast.Children))
+		for i, child := range ast.Children {
+			if values[i], err = evalNode(child); err != nil {
+				return nil, err
+			}
+		}
+		if structured() {
+			for _, q := range values {
+				writeRecord(quantityRecord(statement, q))
+			}
+			return nil, nil
+		}
+		printed := make([]string, len(values))
+		for i, q := range values {
+			printed[i] = plainQuantity(q)
+		}
+		fmt.Println(strings.Join(printed, ", "))
+		return nil, nil
+
+	case ast.Type == parser.NODE_ASSIGN || ast.Type == parser.NODE_CONST || ast.Type == parser.NODE_FORCE:
+		result, err := evalNode(ast)
+		if err == nil {
+			evaluator.Record(result)
+		}
+		return nil, err
+	}
+
+	result, err := evalNode(ast)
+	if err != nil {
+		return nil, err
+	}
+	evaluator.Record(result)
+	return &result, nil
+}
+
+// Script lines handled by handleInput rather than evaluated, as in the REPL
+var (
+	bareCommands     = []string{"clear", "cls", "constants", "convert", "exit", "help", "history", "load", "paths", "quit", "rates", "save", "set", "settings", "units", "variables", "vars"}
+	argumentCommands = []string{"constants", "convert", "load", "precision", "rates", "save", "seed", "set", "units"}
+)
+
+// isCommand reports whether statement is a REPL command rather than an
+// expression
+func isCommand(statement string) bool {
+	name, _, hasArgs := strings.Cut(statement, " ")
+	if hasArgs {
+		return slices.Contains(argumentCommands, name)
+	}
+	return slices.Contains(bareCommands, name)
+}
+
+// splitStatements removes a '#' comment from line and splits the rest at
+// top-level ';' (inside parentheses ';' may separate arguments)
+func splitStatements(line string) []string {
+	line = stripComment(line)
+
+	var statements []string
+	depth, start := 0, 0
+	for i, ch := range line {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ';':
+			if depth == 0 {
+				statements = append(statements, line[start:i])
+				start = i + 1
+			}
+		}
+	}
+	statements = append(statements, line[start:])
+
+	var nonEmpty []string
+	for _, s := range statements {
+		if s = strings.TrimSpace(s); s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return nonEmpty
+}
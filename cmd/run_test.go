@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"Axion/evaluator"
+	"Axion/history"
+	"Axion/settings"
+	"Axion/units"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout returns what f writes to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	return capture(t, &os.Stdout, f)
+}
+
+// captureStderr returns what f writes to stderr
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	return capture(t, &os.Stderr, f)
+}
+
+// capture returns what f writes to the standard stream *file
+func capture(t *testing.T, file **os.File, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	saved := *file
+	*file = w
+	defer func() { *file = saved }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-done
+}
+
+// resetSession gives a test fresh variables, history, settings and a
+// config file of its own
+func resetSession(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	history.File = filepath.Join(dir, "history.json")
+	configPath = filepath.Join(dir, "config.yaml")
+	evaluator.Vars = make(map[string]units.Quantity)
+	evaluator.Results = nil
+	outputFormat, csvHeaderWritten = "text", false
+	t.Cleanup(func() {
+		settings.Apply(settings.Defaults())
+		applySettings()
+		outputFormat, csvHeaderWritten = "text", false
+	})
+}
+
+func TestRunScript(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		script   string
+		expected string
+	}{
+		{"final result", "2 + 2\n3 * 3", "9\n"},
+		{"assignment is not printed", "x = 4\nx^2\ny = 1", ""},
+		{"print", "r = 3\nprint(r, r * 2)", "3, 6\n"},
+		{"statements and comments", "a = 2; b = 3   # sides\na * b", "6\n"},
+		{"continued line", "mean(1, 2,\n     3, 4)", "2.5\n"},
+		{"convert command", "convert 1 km to m", "1 km = 1000 m\n"},
+		{"precision command", "precision 3\n1/3", "Precision set to 3 significant digits (auto display)\n0.333\n"},
+		{"set command", "set angle rad\nasin(1)", "angle set to rad\n1.5708\n"},
+		{"command clears the final result", "2 + 2\nhistory", "no history data\n"},
+		{"exit ends the script", "2 + 2\nexit\n3 + 3", "4\n"},
+		{"clear does nothing", "2 + 2\nclear\ncls", "4\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSession(t)
+			var err error
+			out := captureStdout(t, func() {
+				err = runScript(strings.NewReader(tt.script), "test.ax")
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestRunScript_Vars(t *testing.T) {
+	resetSession(t)
+	out := captureStdout(t, func() {
+		assert.NoError(t, runScript(strings.NewReader("d = 42 km\nvars"), "test.ax"))
+	})
+	assert.Contains(t, out, "Stored Variables")
+	assert.Contains(t, out, "= 42 km")
+}
+
+func TestRunScript_Help(t *testing.T) {
+	resetSession(t)
+	var out string
+	errOut := captureStderr(t, func() {
+		out = captureStdout(t, func() {
+			assert.NoError(t, runScript(strings.NewReader("help\n2 + 2"), "test.ax"))
+		})
+	})
+	assert.Equal(t, "4\n", out)
+	assert.Contains(t, errOut, "Show this help message")
+}
+
+func TestRunScript_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		script string
+		code   int
+	}{
+		{"command name as variable", "seed=4", exitEval},
+		{"syntax error", "2 +* 3", exitSyntax},
+		{"unclosed parenthesis", "sqrt(4", exitSyntax},
+		{"undefined name", "nope + 1", exitEval},
+		{"unit error", "5 km + 2 s", exitUnit},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSession(t)
+			var err error
+			captureStdout(t, func() {
+				err = runScript(strings.NewReader(tt.script), "test.ax")
+			})
+			assert.Equal(t, tt.code, ExitCode(err))
+		})
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	for _, tt := range []struct {
+		statement string
+		expected  bool
+	}{
+		{"convert 1 km to m", true},
+		{"vars", true},
+		{"history", true},
+		{"precision 4", true},
+		{"set angle rad", true},
+		{"exit", true},
+		{"2 + 2", false},
+		{"precision", false},
+		{"history * 2", false},
+		{"x = 5", false},
+		{"paste", false},
+	} {
+		t.Run(tt.statement, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isCommand(tt.statement))
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	for _, tt := range []struct {
+		line     string
+		expected []string
+	}{
+		{"2 + 2", []string{"2 + 2"}},
+		{"r = 3; area = pi * r^2", []string{"r = 3", "area = pi * r^2"}},
+		{"x = 1;; y = 2;", []string{"x = 1", "y = 2"}},
+		{"max(1; 2); 3", []string{"max(1; 2)", "3"}},
+		{"a = 2   # a comment; not a statement", []string{"a = 2"}},
+		{"# only a comment", nil},
+		{"   ", nil},
+	} {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitStatements(tt.line))
+		})
+	}
+}
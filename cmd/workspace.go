@@ -0,0 +1,168 @@
+/*
+Axion CLI Calculator - Workspace Commands
+=========================================
+
+This file connects the workspace module to the CLI:
+
+    save [name]            save variables, settings and custom units
+    load [name]            restore a saved workspace, replacing the variables
+    --workspace <name>     load a workspace before running; a bare save or
+                           load in the REPL then uses it
+
+A name is looked up in the workspaces directory (see paths); a name with a
+'/' or ending in .json is a file path. Without a name, save and load use
+the --workspace workspace, or "default".
+
+With `set autosave on` the REPL saves the session on exit and
+restores it at the next start: to and from the --workspace workspace when
+one is given, otherwise the workspace named "last". Settings restored from
+a workspace apply to the session only; config.yaml and command-line flags
+are left as they are.
+
+Axion has no user-defined functions, so workspaces hold no function
+definitions; when functions are added they should be saved as source in a
+new workspace format version.
+*/
+
+package cmd
+
+import (
+	"Axion/evaluator"
+	"Axion/settings"
+	"Axion/workspace"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// workspaceName is the workspace given with --workspace
+var workspaceName string
+
+// registerWorkspaceFlag adds the --workspace flag to cmd
+func registerWorkspaceFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&workspaceName, "workspace", "", "workspace to load before running (name or .json file)")
+}
+
+// openWorkspace loads the --workspace workspace, if any; a workspace that
+// does not exist yet starts empty and is created by save
+func openWorkspace() error {
+	if workspaceName == "" {
+		return nil
+	}
+	_, err := restoreWorkspace(workspaceName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// restoreWorkspace reads the named workspace and makes it current
+func restoreWorkspace(name string) (workspace.File, error) {
+	f, err := workspace.Read(workspace.Path(name))
+	if err != nil {
+		return f, err
+	}
+	if err := f.Apply(); err != nil {
+		return f, err
+	}
+
+	// Command-line flags and the autosave choice outlive the workspace
+	config := f.Settings
+	current := settings.Current()
+	for key := range overridden {
+		value, _ := current.Get(key)
+		config.Set(key, value)
+	}
+	config.Autosave = settings.Autosave
+	settings.Apply(config)
+	applySettings()
+	return f, nil
+}
+
+// defaultWorkspace returns the workspace used by a bare save or load
+func defaultWorkspace() string {
+	if workspaceName != "" {
+		return workspaceName
+	}
+	return "default"
+}
+
+// sessionWorkspace returns the workspace saved and restored by autosave
+func sessionWorkspace() string {
+	if workspaceName != "" {
+		return workspaceName
+	}
+	return workspace.LastSession
+}
+
+// handleSave processes the REPL save command
+func handleSave(input string) {
+	parts := strings.Fields(input)
+	if len(parts) > 2 {
+		fmt.Println(colorRed + "Usage: " + colorReset + "save [name]")
+		return
+	}
+	name := defaultWorkspace()
+	if len(parts) == 2 {
+		name = parts[1]
+	}
+
+	path := workspace.Path(name)
+	if err := workspace.Save(path); err != nil {
+		fmt.Printf(colorRed+"Error: failed to save workspace: %v\n"+colorReset, err)
+		return
+	}
+	fmt.Printf(colorGreen+"Saved %d variables to %s\n"+colorReset, len(evaluator.Vars), path)
+}
+
+// handleLoad processes the REPL load command
+func handleLoad(input string) {
+	parts := strings.Fields(input)
+	if len(parts) > 2 {
+		fmt.Println(colorRed + "Usage: " + colorReset + "load [name]")
+		return
+	}
+	name := defaultWorkspace()
+	if len(parts) == 2 {
+		name = parts[1]
+	}
+
+	f, err := restoreWorkspace(name)
+	if err != nil {
+		fmt.Printf(colorRed+"Error: %v\n"+colorReset, err)
+		if names := workspace.Names(); errors.Is(err, fs.ErrNotExist) && len(names) > 0 {
+			fmt.Println(colorDim + "   Saved workspaces: " + strings.Join(names, ", ") + colorReset)
+		}
+		return
+	}
+	fmt.Printf(colorGreen+"Loaded %d variables from %s"+colorReset+colorDim+" (saved %s)\n"+colorReset,
+		len(f.Variables), name, f.Saved.Local().Format("2006-01-02 15:04"))
+}
+
+// autoRestore restores the last session when autosave is on
+func autoRestore() {
+	if !settings.Autosave || workspaceName != "" {
+		return // --workspace has been loaded already
+	}
+	f, err := restoreWorkspace(workspace.LastSession)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		warn("Failed to restore the last session: %v", err)
+	case !structured():
+		fmt.Printf(colorDim+"  Restored %d variables from the last session\n\n"+colorReset, len(f.Variables))
+	}
+}
+
+// autoSave saves the session on exit when autosave is on
+func autoSave() {
+	if !settings.Autosave {
+		return
+	}
+	if err := workspace.Save(workspace.Path(sessionWorkspace())); err != nil {
+		warn("Failed to save the session: %v", err)
+	}
+}
@@ -1,26 +1,162 @@
+/*
+Constants Module - Mathematical and Physical Constants Catalog
+==============================================================
+Part of Axion CLI Calculator
+
+This module loads the constants catalog from constants.json. A default
+catalog is embedded in the binary and used when the user has no catalog of
+their own in the config directory. Every constant
+lives in a namespace and is reachable as <namespace>.<symbol>, e.g. phys.c
+or math.pi; commonly used constants also have short aliases (c, pi, hbar).
+Physical constants carry a unit, a standard uncertainty and the source of
+their value (CODATA 2022 unless stated otherwise).
+
+The catalog format is:
+
+    {
+      "source": "CODATA 2022",
+      "constants": [
+        {"namespace": "phys", "symbol": "c", "name": "speed of light in vacuum",
+         "value": 299792458, "unit": "m/s", "uncertainty": 0,
+         "description": "Exact by definition of the metre", "aliases": ["c"]}
+      ]
+    }
+
+For compatibility, a flat {"name": value} object is also accepted; its
+entries are loaded into the "user" namespace with their names as aliases.
+*/
+
 package constants
 
 import (
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 )
 
-var Table map[string]float64
+// Constant describes one entry of the catalog
+type Constant struct {
+	Namespace   string   `json:"namespace"`   // Namespace such as "math" or "phys"
+	Symbol      string   `json:"symbol"`      // Identifier within the namespace
+	Name        string   `json:"name"`        // Human-readable name
+	Value       float64  `json:"value"`       // Value in Unit
+	Unit        string   `json:"unit"`        // Unit expression, empty if dimensionless
+	Uncertainty float64  `json:"uncertainty"` // Standard uncertainty in Unit (0 if exact)
+	Source      string   `json:"source"`      // Origin of the value
+	Description string   `json:"description"` // Short explanation
+	Aliases     []string `json:"aliases"`     // Short names usable without a namespace
+}
+
+// Key returns the namespaced name of the constant (e.g. "phys.c")
+func (c Constant) Key() string {
+	return c.Namespace + "." + c.Symbol
+}
+
+// catalog is the structure of constants.json
+type catalog struct {
+	Source    string     `json:"source"`
+	Constants []Constant `json:"constants"`
+}
+
+// defaultCatalog is the constants.json shipped with Axion
+//
+//go:embed constants.json
+var defaultCatalog []byte
+
+// DefaultSource is reported by Loaded when the embedded catalog is in use
+const DefaultSource = "embedded default"
+
+// Catalog lists every loaded constant in file order
+var Catalog []Constant
+
+// Loaded describes where the current catalog came from
+var Loaded string
+
+// lookup maps namespaced keys and aliases to catalog entries
+var lookup = make(map[string]Constant)
 
+// Load reads the constants catalog from file, replacing any previous catalog
 func Load(file string) error {
 	f, err := os.ReadFile(file)
 	if err != nil {
 		return fmt.Errorf("failed to read constants file: %w", err)
 	}
-	Table = make(map[string]float64)
-	if err := json.Unmarshal(f, &Table); err != nil {
-		return fmt.Errorf("failed to parse constants: %w", err)
+	if err := Parse(f); err != nil {
+		return err
 	}
+	Loaded = file
 	return nil
 }
 
-func Get(name string) (float64, bool) {
-	val, ok := Table[name]
-	return val, ok
+// LoadDefault loads the catalog embedded in the binary
+func LoadDefault() error {
+	if err := Parse(defaultCatalog); err != nil {
+		return err
+	}
+	Loaded = DefaultSource
+	return nil
+}
+
+// Parse loads a constants catalog from JSON data
+func Parse(data []byte) error {
+	var c catalog
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Constants) == 0 {
+		// Fall back to the legacy flat format
+		var flat map[string]float64
+		if flatErr := json.Unmarshal(data, &flat); flatErr != nil {
+			if err == nil {
+				err = flatErr
+			}
+			return fmt.Errorf("failed to parse constants: %w", err)
+		}
+		c = catalog{}
+		for name, value := range flat {
+			c.Constants = append(c.Constants, Constant{Namespace: "user", Symbol: name, Name: name, Value: value, Aliases: []string{name}})
+		}
+		sort.Slice(c.Constants, func(i, j int) bool { return c.Constants[i].Symbol < c.Constants[j].Symbol })
+	}
+
+	entries := make(map[string]Constant)
+	for i := range c.Constants {
+		constant := &c.Constants[i]
+		if constant.Namespace == "" || constant.Symbol == "" {
+			return fmt.Errorf("constant %q needs a namespace and a symbol", constant.Name)
+		}
+		if constant.Source == "" {
+			constant.Source = c.Source
+		}
+		for _, key := range append([]string{constant.Key()}, constant.Aliases...) {
+			if other, exists := entries[key]; exists {
+				return fmt.Errorf("constant name %q is used by both %s and %s", key, other.Key(), constant.Key())
+			}
+			entries[key] = *constant
+		}
+	}
+
+	Catalog = c.Constants
+	lookup = entries
+	return nil
+}
+
+// Get returns the constant with the given namespaced name or alias
+func Get(name string) (Constant, bool) {
+	c, ok := lookup[name]
+	return c, ok
+}
+
+// Search returns the constants whose key, name, aliases or description
+// contain query (case-insensitive); an empty query matches everything
+func Search(query string) []Constant {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matches []Constant
+	for _, c := range Catalog {
+		text := strings.ToLower(strings.Join(append([]string{c.Key(), c.Name, c.Description}, c.Aliases...), " "))
+		if strings.Contains(text, query) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
 }
@@ -30,17 +30,22 @@ Supported Operations:
 - Advanced Math: Factorial with domain validation (non-negative integers ≤ 170)
 
 Mathematical Functions:
-- Trigonometric: sin, cos, tan, asin, acos, atan (degree-based)
+- Trigonometric: sin, cos, tan, asin, acos, atan (degrees or radians per the angle
+  setting, angle units accepted)
 - Logarithmic: ln, log, log10, log2 with custom base support
 - Exponential: exp with overflow protection
 - Utility: abs, ceil, floor, round, trunc, sign
 - Power Functions: pow, sqrt with domain validation
 - Statistical: mean, median, mode, sum, product for multi-argument support
 - Comparison: max, min for pairwise operations
+- Random: rand, randint, randn, choice and simulate (Monte Carlo summaries)
 
 Advanced Features:
 - Variable Storage: Persistent variable assignment and retrieval
-- Constant Integration: Mathematical constants (pi, e, etc.) from constants module
+- Constant Integration: Catalog constants by namespace (phys.c, math.pi) or alias
+  (c, pi), physical constants carrying their units (c is 299792458 m/s)
+  except as direct arguments of plain-number functions (sqrt(c), max(G, 1))
+- Dimensional Analysis: Values may carry units (5 km + 300 m, 60 mi / 2 h in km/h)
 - Domain Validation: Prevents invalid operations (sqrt of negative, log of non-positive)
 - Overflow Protection: Guards against numerical overflow in computations
 - Type Safety: Ensures proper argument counts and types for all functions
@@ -56,7 +61,11 @@ Variable System:
 - Dynamic Assignment: Runtime variable creation and modification
 - Scope Management: Global variable storage across expressions
 - Constant Access: Integration with predefined mathematical constants
+- Read-Only Values: Built-in constants and const declarations reject assignment
+  unless forced (force pi = 3)
 - Name Resolution: Identifier lookup with proper error reporting
+- Result References: ans or _ for the last result, $n for numbered results and
+  @hN for history entries (see results.go)
 
 The evaluator ensures mathematical correctness while providing comprehensive
 error handling and support for advanced mathematical operations.
@@ -66,13 +75,24 @@ package evaluator
 import (
 	"Axion/constants"
 	"Axion/parser"
+	"Axion/settings"
+	"Axion/units"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strconv"
 )
 
-var Vars = make(map[string]float64)
+var Vars = make(map[string]units.Quantity)
+
+// ReadOnly marks the variables declared with const
+var ReadOnly = make(map[string]bool)
+
+// Commands lists the REPL commands that take arguments (set, load, ...);
+// a line starting with one of them runs the command, so a variable of that
+// name could never be used. The cmd package fills it in
+var Commands []string
 
 // factorial computes the factorial function with overflow protection
 func factorial(n float64) (float64, error) {
@@ -93,13 +113,13 @@ func derivative(node *parser.Node, point float64) (float64, error) {
     
     originalX, hadX := Vars["x"]
     
-    Vars["x"] = point + h
+    Vars["x"] = units.Scalar(point + h)
     fPlus, err := Eval(node)
     if err != nil {
         return 0, err
     }
     
-    Vars["x"] = point - h
+    Vars["x"] = units.Scalar(point - h)
     fMinus, err := Eval(node)
     if err != nil {
         return 0, err
@@ -123,13 +143,13 @@ func integral(node *parser.Node, a, b float64) (float64, error) {
     originalX, hadX := Vars["x"]
     
     // Simpson's rule: (h/3) * [f(a) + 4*f(mid) + 2*f(even) + ... + f(b)]
-    Vars["x"] = a
+    Vars["x"] = units.Scalar(a)
     fa, err := Eval(node)
     if err != nil {
         return 0, err
     }
     
-    Vars["x"] = b
+    Vars["x"] = units.Scalar(b)
     fb, err := Eval(node)
     if err != nil {
         return 0, err
@@ -138,7 +158,7 @@ func integral(node *parser.Node, a, b float64) (float64, error) {
     sum := fa + fb
     
     for i := 1; i < n; i++ {
-        Vars["x"] = a + float64(i)*h
+        Vars["x"] = units.Scalar(a + float64(i)*h)
         fx, err := Eval(node)
         if err != nil {
             return 0, err
@@ -172,122 +192,353 @@ func fibb(n int) (float64,error) {
     return b,nil
 }
 // Eval recursively evaluates an AST node and returns its numeric value
+// Quantities carrying a unit are rejected; use EvalQuantity for those
 func Eval(node *parser.Node) (float64, error) {
+	q, err := EvalQuantity(node)
+	if err != nil {
+		return 0, err
+	}
+	if !q.Dim.IsZero() {
+		if c, ok := catalogConstant(node); ok {
+			return c.Value, nil
+		}
+		_, unit := q.Display()
+		return 0, units.Errorf("expected a plain number but got a quantity in %s", unit)
+	}
+	return q.Value, nil
+}
+
+// catalogConstant returns the constant that node names directly. Such a
+// constant is taken as its catalog value, in its catalog unit, where a plain
+// number is expected: sqrt(c) is sqrt(299792458), as before constants
+// carried units
+func catalogConstant(node *parser.Node) (constants.Constant, bool) {
+	if node.Type != parser.NODE_IDENTIFIER || IsReference(node.Value) {
+		return constants.Constant{}, false
+	}
+	if _, shadowed := Vars[node.Value]; shadowed {
+		return constants.Constant{}, false
+	}
+	return constants.Get(node.Value)
+}
+
+// EvalQuantity evaluates an AST node to a value that may carry a unit
+// Arithmetic, comparisons, assignments and conversions track dimensions;
+// all other nodes are evaluated as plain numbers
+func EvalQuantity(node *parser.Node) (units.Quantity, error) {
 	if node == nil {
-		return 0, fmt.Errorf("invalid node")
+		return units.Quantity{}, fmt.Errorf("invalid node")
 	}
 
 	switch node.Type {
 	case parser.NODE_NUMBER:
 		val, err := strconv.ParseFloat(node.Value, 64)
 		if err != nil {
-			return 0, fmt.Errorf("invalid number %q", node.Value)
+			return units.Quantity{}, fmt.Errorf("invalid number %q", node.Value)
 		}
-		return val, nil
+		return units.Scalar(val), nil
 
-	case parser.NODE_ASSIGN:
-		val, err := Eval(node.Right)
+	case parser.NODE_ASSIGN, parser.NODE_CONST, parser.NODE_FORCE:
+		if IsReference(node.Value) {
+			return units.Quantity{}, fmt.Errorf("cannot assign to %s: it refers to an earlier result", node.Value)
+		}
+		if err := checkAssignable(node.Value, node.Type == parser.NODE_FORCE); err != nil {
+			return units.Quantity{}, err
+		}
+		val, err := EvalQuantity(node.Right)
 		if err != nil {
-			return 0, err
+			return units.Quantity{}, err
 		}
 		Vars[node.Value] = val
+		if node.Type == parser.NODE_CONST {
+			ReadOnly[node.Value] = true
+		} else {
+			delete(ReadOnly, node.Value)
+		}
 		return val, nil
 
 	case parser.NODE_IDENTIFIER:
+		if IsReference(node.Value) {
+			return reference(node.Value)
+		}
 		if v, ok := Vars[node.Value]; ok {
 			return v, nil
 		}
-		if v, ok := constants.Get(node.Value); ok {
+		if c, ok := constants.Get(node.Value); ok {
+			return constantQuantity(c)
+		}
+		if u, ok := units.Lookup(node.Value); ok {
+			return u.Quantity(), nil
+		}
+		return units.Quantity{}, fmt.Errorf("undefined variable or constant %s", node.Value)
+
+	case parser.NODE_UNIT:
+		// Unit annotations prefer units over constants ("2 h" is two hours),
+		// but user variables and result references still take precedence
+		if IsReference(node.Value) {
+			return reference(node.Value)
+		}
+		if v, ok := Vars[node.Value]; ok {
 			return v, nil
 		}
-		return 0, fmt.Errorf("undefined variable or constant %s", node.Value)
+		if u, ok := units.Lookup(node.Value); ok {
+			return u.Quantity(), nil
+		}
+		return units.Quantity{}, units.Errorf("unknown unit %s", node.Value)
+
+	case parser.NODE_CONVERT:
+		val, err := EvalQuantity(node.Left)
+		if err != nil {
+			return units.Quantity{}, err
+		}
+		target, err := evalUnit(node.Right)
+		if err != nil {
+			return units.Quantity{}, err
+		}
+		return units.In(val, target, node.Value)
 
 	case parser.NODE_OPERATOR:
 		if node.Value == "neg" {
-			left, err := Eval(node.Left)
+			left, err := EvalQuantity(node.Left)
 			if err != nil {
-				return 0, err
+				return units.Quantity{}, err
 			}
-			return -left, nil
+			return units.Neg(left)
 		}
 
-		left, err := Eval(node.Left)
+		left, err := EvalQuantity(node.Left)
 		if err != nil {
-			return 0, err
+			return units.Quantity{}, err
 		}
-		right, err := Eval(node.Right)
+		// Temperature readings: "20 C" is 293.15 K, not 20 K, and is absolute
+		if u, ok := readingAnnotation(node); ok {
+			return u.Reading(left)
+		}
+		right, err := EvalQuantity(node.Right)
 		if err != nil {
-			return 0, err
+			return units.Quantity{}, err
 		}
 		switch node.Value {
 		case "+":
-			return left + right, nil
+			return units.Add(left, right)
 		case "-":
-			return left - right, nil
+			return units.Sub(left, right)
 		case "*":
-			return left * right, nil
+			return units.Mul(left, right)
 		case "/":
-			if right == 0 {
-				return 0, fmt.Errorf("division by zero")
-			}
-			return left / right, nil
+			return units.Div(left, right)
 		case "^":
-			if right > 500 {
-				return 0, fmt.Errorf("exponent too large: maximum allowed is 500")
+			if !right.Dim.IsZero() {
+				return units.Quantity{}, units.Errorf("exponent must be a plain number")
 			}
-			result := math.Pow(left, right)
-			if math.IsInf(result, 0) {
-				return 0, fmt.Errorf("exponentiation overflow")
+			if right.Value > 500 {
+				return units.Quantity{}, fmt.Errorf("exponent too large: maximum allowed is 500")
 			}
-			if math.IsNaN(result) {
-				return 0, fmt.Errorf("exponentiation produced invalid result")
+			result, err := units.Pow(left, right.Value)
+			if err != nil {
+				return units.Quantity{}, err
+			}
+			if math.IsInf(result.Value, 0) {
+				return units.Quantity{}, fmt.Errorf("exponentiation overflow")
+			}
+			if math.IsNaN(result.Value) {
+				return units.Quantity{}, fmt.Errorf("exponentiation produced invalid result")
 			}
 			return result, nil
 		default:
-			return 0, fmt.Errorf("unknown operator %q", node.Value)
+			return units.Quantity{}, fmt.Errorf("unknown operator %q", node.Value)
 		}
+
 	case parser.NODE_COMPARISON:
-		left, err := Eval(node.Left)
+		left, err := EvalQuantity(node.Left)
 		if err != nil {
-			return 0, err
+			return units.Quantity{}, err
 		}
 
-		right, err := Eval(node.Right)
+		right, err := EvalQuantity(node.Right)
 		if err != nil {
-			return 0, err
+			return units.Quantity{}, err
+		}
+		cmp, err := units.Compare(left, right)
+		if err != nil {
+			return units.Quantity{}, err
 		}
+		var result bool
 		switch node.Value {
 		case ">":
-			if left > right {
-				return 1.0, nil
-			}
-			return 0.0, nil
+			result = cmp > 0
 		case "<":
-			if left < right {
-				return 1.0, nil
-			}
-			return 0.0, nil
+			result = cmp < 0
 		case ">=":
-			if left >= right {
-				return 1.0, nil
-			}
-			return 0.0, nil
+			result = cmp >= 0
 		case "<=":
-			if left <= right {
-				return 1.0, nil
-			}
-			return 0.0, nil
+			result = cmp <= 0
 		case "==":
-			if left == right {
-				return 1.0, nil
-			}
-			return 0.0, nil
+			result = cmp == 0
 		case "!=":
-			if left != right {
-				return 1.0, nil
+			result = cmp != 0
+		default:
+			return units.Quantity{}, fmt.Errorf("unknown comparison %q", node.Value)
+		}
+		if result {
+			return units.Scalar(1), nil
+		}
+		return units.Scalar(0), nil
+	}
+
+	val, err := evalScalar(node)
+	if err != nil {
+		return units.Quantity{}, err
+	}
+	return units.Scalar(val), nil
+}
+
+// checkAssignable rejects assignments to command names, read-only variables
+// and names of built-in constants; "force name = expr" (force) bypasses all
+// but the command names
+func checkAssignable(name string, force bool) error {
+	if slices.Contains(Commands, name) {
+		return fmt.Errorf("cannot assign to %s: it is a command name, and a line starting with '%s ' runs the command", name, name)
+	}
+	if force {
+		return nil
+	}
+	if ReadOnly[name] {
+		return fmt.Errorf("cannot assign to constant %s (use 'force %s = ...' to redefine it)", name, name)
+	}
+	if _, isVar := Vars[name]; isVar {
+		return nil // already shadowed with force
+	}
+	if c, ok := constants.Get(name); ok {
+		builtin := name
+		if c.Key() != name {
+			builtin += " (" + c.Key() + ")"
+		}
+		return fmt.Errorf("cannot assign to built-in constant %s; use 'force %s = ...' to shadow it", builtin, name)
+	}
+	return nil
+}
+
+// Shadowed describes the built-in constant or unit hidden by variable
+// name, or returns "" if the name shadows nothing
+func Shadowed(name string) string {
+	if c, ok := constants.Get(name); ok {
+		return "constant " + c.Key()
+	}
+	if u, ok := units.Lookup(name); ok {
+		if u.Name != "" {
+			return "unit " + u.Name
+		}
+		return "unit " + u.Symbol
+	}
+	return ""
+}
+
+// readingAnnotation returns the unit of a "<value> <unit>" node whose unit
+// is an absolute temperature scale, such as kelvin or degrees Celsius
+func readingAnnotation(node *parser.Node) (units.Unit, bool) {
+	if node.Value != "*" || node.Right == nil || node.Right.Type != parser.NODE_UNIT {
+		return units.Unit{}, false
+	}
+	if _, shadowed := Vars[node.Right.Value]; shadowed {
+		return units.Unit{}, false
+	}
+	u, ok := units.Lookup(node.Right.Value)
+	return u, ok && u.Absolute
+}
+
+// evalUnit evaluates a conversion target where every name must be a unit
+func evalUnit(node *parser.Node) (units.Quantity, error) {
+	switch node.Type {
+	case parser.NODE_NUMBER:
+		return EvalQuantity(node)
+	case parser.NODE_IDENTIFIER, parser.NODE_UNIT:
+		if u, ok := units.Lookup(node.Value); ok {
+			return u.Quantity(), nil
+		}
+		return units.Quantity{}, units.Errorf("unknown unit %s", node.Value)
+	case parser.NODE_OPERATOR:
+		left, err := evalUnit(node.Left)
+		if err != nil {
+			return units.Quantity{}, err
+		}
+		if node.Value == "neg" {
+			return units.Neg(left)
+		}
+		right, err := evalUnit(node.Right)
+		if err != nil {
+			return units.Quantity{}, err
+		}
+		switch node.Value {
+		case "*":
+			return units.Mul(left, right)
+		case "/":
+			return units.Div(left, right)
+		case "^":
+			if !right.Dim.IsZero() {
+				return units.Quantity{}, units.Errorf("exponent must be a plain number")
 			}
-			return 0.0, nil
+			return units.Pow(left, right.Value)
 		}
+	}
+	return units.Quantity{}, fmt.Errorf("invalid target unit")
+}
+
+// constantQuantity converts a catalog constant into a quantity in its unit
+func constantQuantity(c constants.Constant) (units.Quantity, error) {
+	if c.Unit == "" {
+		return units.Scalar(c.Value), nil
+	}
+	u, err := units.Parse(c.Unit)
+	if err != nil {
+		return units.Quantity{}, fmt.Errorf("constant %s has invalid unit: %v", c.Key(), err)
+	}
+	return units.Quantity{Value: c.Value * u.Factor, Dim: u.Dim, Unit: c.Unit, Scale: u.Factor}, nil
+}
+
+// toRadians converts an angle in the current angle mode to radians
+func toRadians(angle float64) float64 {
+	if settings.AngleMode == "rad" {
+		return angle
+	}
+	return angle * math.Pi / 180
+}
+
+// fromRadians converts an angle in radians to the current angle mode
+func fromRadians(angle float64) float64 {
+	if settings.AngleMode == "rad" {
+		return angle
+	}
+	return angle * 180 / math.Pi
+}
+
+// evalArgument evaluates a function argument as a plain number; sin, cos and
+// tan also accept angle quantities (90 deg, 1.2 rad), converted to the
+// current angle mode
+func evalArgument(name string, arg *parser.Node) (float64, error) {
+	if name != "sin" && name != "cos" && name != "tan" {
+		return Eval(arg)
+	}
+	q, err := EvalQuantity(arg)
+	if err != nil {
+		return 0, err
+	}
+	if q.Dim == (units.Dimension{units.Angle: 1}) {
+		return fromRadians(q.Value), nil
+	}
+	if c, ok := catalogConstant(arg); ok && !q.Dim.IsZero() {
+		return c.Value, nil
+	}
+	if !q.Dim.IsZero() {
+		_, unit := q.Display()
+		return 0, units.Errorf("%s expects an angle but got a quantity in %s", name, unit)
+	}
+	return q.Value, nil
+}
+
+// evalScalar evaluates logical operators and function calls on plain numbers
+func evalScalar(node *parser.Node) (float64, error) {
+	switch node.Type {
 	case parser.NODE_OR:
 		left, err := Eval(node.Left)
 		if err != nil {
@@ -323,32 +574,32 @@ func Eval(node *parser.Node) (float64, error) {
 			if len(node.Children) < 1 {
 				return 0, fmt.Errorf("%s requires 1 argument", node.Value)
 			}
-			arg1, err := Eval(node.Children[0])
+			arg1, err := evalArgument(node.Value, node.Children[0])
 			if err != nil {
 				return 0, err
 			}
 			switch node.Value {
 			case "sin":
-				return math.Sin(arg1 * math.Pi / 180), nil
+				return math.Sin(toRadians(arg1)), nil
 			case "cos":
-				return math.Cos(arg1 * math.Pi / 180), nil
+				return math.Cos(toRadians(arg1)), nil
 			case "tan":
-				if math.Mod(arg1, 180) == 90 {
+				if settings.AngleMode != "rad" && math.Mod(arg1, 180) == 90 {
 					return 0, fmt.Errorf("tan(%g°): undefined (asymptote)", arg1)
 				}
-				return math.Tan(arg1 * math.Pi / 180), nil
+				return math.Tan(toRadians(arg1)), nil
 			case "asin":
 				if arg1 < -1 || arg1 > 1 {
 					return 0, fmt.Errorf("asin: domain error, input must be [-1,1]")
 				}
-				return math.Asin(arg1) * 180 / math.Pi, nil
+				return fromRadians(math.Asin(arg1)), nil
 			case "acos":
 				if arg1 < -1 || arg1 > 1 {
 					return 0, fmt.Errorf("acos: domain error, input must be [-1,1]")
 				}
-				return math.Acos(arg1) * 180 / math.Pi, nil
+				return fromRadians(math.Acos(arg1)), nil
 			case "atan":
-				return math.Atan(arg1) * 180 / math.Pi, nil
+				return fromRadians(math.Atan(arg1)), nil
 			case "sqrt":
 				if arg1 < 0 {
 					return 0, fmt.Errorf("sqrt: negative number %g", arg1)
@@ -559,7 +810,7 @@ func Eval(node *parser.Node) (float64, error) {
 			case "min":
 				return math.Min(arg1, arg2), nil
 			case "atan2":
-				return math.Atan2(arg1, arg2) * 180 / math.Pi, nil
+				return fromRadians(math.Atan2(arg1, arg2)), nil
 			case "mod":
 				if arg2 == 0 {
 					return 0, fmt.Errorf("mod: division by zero")
@@ -604,6 +855,67 @@ case "derivative":
     }
     
     return derivative(expression, point)
+
+		// RANDOM FUNCTIONS
+		case "rand":
+			if len(node.Children) != 0 {
+				return 0, fmt.Errorf("rand takes no arguments")
+			}
+			return rng.Float64(), nil
+
+		case "randint":
+			if len(node.Children) != 2 {
+				return 0, fmt.Errorf("randint requires 2 arguments: randint(a, b)")
+			}
+			a, err := Eval(node.Children[0])
+			if err != nil {
+				return 0, err
+			}
+			b, err := Eval(node.Children[1])
+			if err != nil {
+				return 0, err
+			}
+			return randInt(a, b)
+
+		case "randn":
+			if len(node.Children) == 0 {
+				return randNormal(0, 1)
+			}
+			if len(node.Children) != 2 {
+				return 0, fmt.Errorf("randn accepts 0 or 2 arguments: randn(mu, sigma)")
+			}
+			mu, err := Eval(node.Children[0])
+			if err != nil {
+				return 0, err
+			}
+			sigma, err := Eval(node.Children[1])
+			if err != nil {
+				return 0, err
+			}
+			return randNormal(mu, sigma)
+
+		case "choice":
+			if len(node.Children) < 1 {
+				return 0, fmt.Errorf("choice requires at least 1 argument")
+			}
+			// Only the selected argument is evaluated
+			return Eval(node.Children[rng.Intn(len(node.Children))])
+
+		case "simulate":
+			if len(node.Children) != 2 {
+				return 0, fmt.Errorf("simulate requires 2 arguments: simulate(expression, n)")
+			}
+			n, err := Eval(node.Children[1])
+			if err != nil {
+				return 0, err
+			}
+			stats, err := simulate(node.Children[0], n)
+			if err != nil {
+				return 0, err
+			}
+			LastSimulation = &stats
+			return stats.Mean, nil
+
 		default:
 			return 0, fmt.Errorf("unknown function %q", node.Value)
 		}
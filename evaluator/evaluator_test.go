@@ -1,9 +1,15 @@
 package evaluator
 
 import (
+	"Axion/constants"
+	"Axion/history"
 	"Axion/parser"
+	"Axion/settings"
 	"Axion/tokenizer"
+	"Axion/units"
+	"encoding/json"
 	"math"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -194,7 +200,7 @@ func TestEvaluator(t *testing.T) {
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.name == "var persistence 1" {
-				Vars = make(map[string]float64)
+				Vars = make(map[string]units.Quantity)
 			}
 
 			tokens, err := tokenizer.Tokenize(tt.input)
@@ -245,7 +251,7 @@ func TestEvaluator_StatisticalFunctions(t *testing.T) {
 		{"mode all unique", "mode(1,2,3,4)", 1}, // Returns first value
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			Vars = make(map[string]float64)
+			Vars = make(map[string]units.Quantity)
 
 			tokens, err := tokenizer.Tokenize(tt.input)
 			assert.NoError(t, err)
@@ -284,7 +290,7 @@ func TestEvaluator_ErrorMessages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			Vars = make(map[string]float64)
+			Vars = make(map[string]units.Quantity)
 
 			tokens, err := tokenizer.Tokenize(tt.input)
 			if err != nil {
@@ -302,3 +308,451 @@ func TestEvaluator_ErrorMessages(t *testing.T) {
 		})
 	}
 }
+
+func evalString(t *testing.T, input string) (float64, error) {
+	t.Helper()
+	tokens, err := tokenizer.Tokenize(input)
+	assert.NoError(t, err, "tokenizer error for input %q", input)
+
+	p := parser.Parser{Tokens: tokens}
+	ast, err := p.ParseExpression()
+	assert.NoError(t, err, "parser error for input %q", input)
+	return Eval(ast)
+}
+
+func TestEvaluator_RandomFunctions(t *testing.T) {
+	Vars = make(map[string]units.Quantity)
+
+	Seed(42)
+	first, err := evalString(t, "rand() + randint(1, 6) + randn(10, 2)")
+	assert.NoError(t, err)
+	Seed(42)
+	second, err := evalString(t, "rand() + randint(1, 6) + randn(10, 2)")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second, "same seed must reproduce the same result")
+
+	for i := 0; i < 100; i++ {
+		r, err := evalString(t, "rand()")
+		assert.NoError(t, err)
+		assert.True(t, r >= 0 && r < 1)
+
+		n, err := evalString(t, "randint(-2, 2)")
+		assert.NoError(t, err)
+		assert.True(t, n >= -2 && n <= 2 && n == math.Floor(n))
+
+		c, err := evalString(t, "choice(1, 5, 9)")
+		assert.NoError(t, err)
+		assert.Contains(t, []float64{1, 5, 9}, c)
+	}
+
+	for _, input := range []string{
+		"rand(1)", "randint(5, 1)", "randint(1.5, 3)", "randn(0, -1)", "randn(1)", "choice()",
+		"randint(0, 9223372036854775807)", // a range of 2^63 values overflows int64
+		"randint(-1e300, 1e300)",
+	} {
+		_, err := evalString(t, input)
+		assert.Error(t, err, "expected error for input %q", input)
+	}
+}
+
+func TestEvaluator_Simulate(t *testing.T) {
+	Vars = make(map[string]units.Quantity)
+	Seed(7)
+
+	mean, err := evalString(t, "simulate(randn(100, 15), 20000)")
+	assert.NoError(t, err)
+	assert.InDelta(t, 100, mean, 0.5)
+	assert.NotNil(t, LastSimulation)
+	assert.Equal(t, 20000, LastSimulation.N)
+	assert.InDelta(t, 15, LastSimulation.StdDev, 0.5)
+	assert.True(t, LastSimulation.P5 < LastSimulation.P50 && LastSimulation.P50 < LastSimulation.P95)
+
+	constant, err := evalString(t, "simulate(3, 10)")
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, constant)
+	assert.Equal(t, 0.0, LastSimulation.StdDev)
+
+	_, err = evalString(t, "simulate(rand(), 1)")
+	assert.Error(t, err)
+	_, err = evalString(t, "simulate(rand(), 2.5)")
+	assert.Error(t, err)
+}
+
+func evalQuantityString(t *testing.T, input string) (units.Quantity, error) {
+	t.Helper()
+	tokens, err := tokenizer.Tokenize(input)
+	assert.NoError(t, err, "tokenizer error for input %q", input)
+
+	p := parser.Parser{Tokens: tokens}
+	ast, err := p.ParseExpression()
+	assert.NoError(t, err, "parser error for input %q", input)
+	return EvalQuantity(ast)
+}
+
+func TestEvaluator_Quantities(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		input    string
+		expected float64
+		unit     string
+	}{
+		{"addition converts units", "5 km + 300 m", 5.3, "km"},
+		{"subtraction converts units", "1 h - 30 min", 0.5, "h"},
+		{"speed", "60 mi / 2 h", 30, "mi/h"},
+		{"speed in km/h", "60 mi / 2 h in km/h", 48.2802, "km/h"},
+		{"area", "2 m * 3 m", 6, "m^2"},
+		{"power of unit", "(2 m)^2", 4, "m^2"},
+		{"scalar scaling", "2 * 5 kg", 10, "kg"},
+		{"display in target", "1500 m to km", 1.5, "km"},
+		{"inch to cm", "12 in in cm", 30.48, "cm"},
+		{"hours beat planck constant", "2 h to min", 120, "min"},
+		{"dimensionless ratio", "1 km / 1 m", 1000, ""},
+		{"force in base units", "10 kg * 9.8 m / 1 s^2 in kg*m/s^2", 98, "kg*m/s^2"},
+		{"celsius reading to fahrenheit", "100 C in F", 212, "F"},
+		{"fahrenheit reading to kelvin", "32 F to K", 273.15, "K"},
+		{"temperature plus difference", "20 C + 5 deltaC", 25, "C"},
+		{"energy from power and time", "2 kW * 3 h in kWh", 6, "kWh"},
+		{"pressure from force and area", "10 N / 2 m2 in Pa", 5, "Pa"},
+		{"data rate", "1 GiB / 8 s in MiB/s", 128, "MiB/s"},
+		{"sine of angle quantity", "sin(90 deg)", 1, ""},
+		{"cosine of radians", "cos(0.5 rev)", -1, ""},
+		{"unit names and plurals", "3 miles in kilometers", 4.82802, "kilometers"},
+		{"unicode prefix", "5 µm + 3 um", 8, "µm"},
+		{"degree sign", "sin(90°)", 1, ""},
+		{"degrees celsius symbol", "100 °C in °F", 212, "°F"},
+		{"ohms law", "2 kΩ * 3 mA in V", 6, "V"},
+		{"two-word unit", "8 fl oz in mL", 236.5882365, "mL"},
+		{"two-word target", "250 mL in fl oz", 8.453505675, "fl oz"},
+		{"cancelled unit", "3 m/s * 2 s", 6, "m"},
+		{"acceleration", "4 m/s / 2 s", 2, "m/s^2"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			Vars = make(map[string]units.Quantity)
+			q, err := evalQuantityString(t, tt.input)
+			assert.NoError(t, err)
+			value, unit := q.Display()
+			assert.InDelta(t, tt.expected, value, 1e-9)
+			assert.Equal(t, tt.unit, unit)
+		})
+	}
+
+	for _, input := range []string{"5 km + 2 s", "1 kg > 1 m", "60 mi / 2 h in kg", "sqrt(4 m)", "2^(3 s)", "5 m in xyz", "-300 C", "sin(2 m)", "1 J in W"} {
+		t.Run("error "+input, func(t *testing.T) {
+			Vars = make(map[string]units.Quantity)
+			_, err := evalQuantityString(t, input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEvaluator_UnitErrors(t *testing.T) {
+	for _, input := range []string{"5 km + 2 s", "1 kg > 1 m", "sqrt(4 m)", "2^(3 s)", "sin(3 m)", "max(2 kg, 1)", "5 m in xyz"} {
+		t.Run(input, func(t *testing.T) {
+			Vars = make(map[string]units.Quantity)
+			_, err := evalQuantityString(t, input)
+			var unitErr *units.UnitError
+			assert.ErrorAs(t, err, &unitErr)
+		})
+	}
+}
+
+func TestEvaluator_Temperatures(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		input    string
+		expected float64
+		unit     string
+	}{
+		{"difference of readings", "30 C - 20 C", 10, "deltaC"},
+		{"difference across scales", "300 K - 20 C", 6.85, "deltaK"},
+		{"difference in fahrenheit", "212 F - 32 F", 180, "deltaF"},
+		{"reading plus difference", "20 C + (30 C - 25 C)", 25, "C"},
+		{"difference plus reading", "5 deltaC + 20 C", 25, "C"},
+		{"reading minus difference", "20 C - 5 deltaC", 15, "C"},
+		{"kelvin reading scales", "300 K * 2", 600, "K"},
+		{"gas law", "1 mol * 8.314 J/(mol*deltaK) * 300 K in J", 2494.2, "J"},
+		{"scaled difference", "(30 C - 20 C) * 2", 20, "deltaC"},
+		{"difference in kelvin", "(30 C - 20 C) in K", 10, "K"},
+		{"equal readings across scales", "20 C == 68 F", 1, ""},
+		{"readings compared", "300 K > 20 C", 1, ""},
+		{"differences compared", "5 deltaC > 10 deltaF", 0, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			Vars = make(map[string]units.Quantity)
+			q, err := evalQuantityString(t, tt.input)
+			assert.NoError(t, err)
+			value, unit := q.Display()
+			assert.InDelta(t, tt.expected, value, 1e-9)
+			assert.Equal(t, tt.unit, unit)
+		})
+	}
+
+	for _, input := range []string{
+		"20 C + 30 C",        // absolute + absolute
+		"300 K + 20 C",       // absolute + absolute
+		"20 C * 2",           // scaled reading
+		"2 * 20 F",           // scaled reading
+		"20 C / 2",           // scaled reading
+		"(20 C)^2",           // powered reading
+		"5 deltaC - 20 C",    // difference - absolute
+		"-(20 C)",            // negated reading
+		"20 C - 300 deltaC",  // below absolute zero
+		"(30 C - 20 C) in C", // difference as a reading
+		"20 C in deltaF",     // reading as a difference
+		"20 C > 5 deltaC",    // reading compared with a difference
+	} {
+		t.Run("error "+input, func(t *testing.T) {
+			Vars = make(map[string]units.Quantity)
+			_, err := evalQuantityString(t, input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEvaluator_CustomUnitNames(t *testing.T) {
+	Vars = make(map[string]units.Quantity)
+	_, err := units.LoadCustom([]json.RawMessage{
+		json.RawMessage(`{"units":[{"symbol":"U","name":"rack unit","definition":"1.75 in"}]}`),
+	})
+	assert.NoError(t, err)
+
+	for _, input := range []string{"3 rack units in cm", "3 rack   units in cm", "3 U in cm", "1 rack unit * 3 in cm"} {
+		t.Run(input, func(t *testing.T) {
+			q, err := evalQuantityString(t, input)
+			assert.NoError(t, err)
+			value, unit := q.Display()
+			assert.InDelta(t, 13.335, value, 1e-9)
+			assert.Equal(t, "cm", unit)
+		})
+	}
+}
+
+func TestEvaluator_QuantityVariables(t *testing.T) {
+	Vars = make(map[string]units.Quantity)
+
+	_, err := evalQuantityString(t, "d = 42 km")
+	assert.NoError(t, err)
+	speed, err := evalQuantityString(t, "d / 3 h in km/h")
+	assert.NoError(t, err)
+	value, unit := speed.Display()
+	assert.InDelta(t, 14, value, 1e-9)
+	assert.Equal(t, "km/h", unit)
+
+	// User variables shadow unit names in annotations
+	_, err = evalQuantityString(t, "m = 4")
+	assert.NoError(t, err)
+	got, err := evalString(t, "2m")
+	assert.NoError(t, err)
+	assert.Equal(t, 8.0, got)
+
+	_, err = evalString(t, "d")
+	assert.Error(t, err, "Eval must reject quantities with units")
+}
+
+func TestEvaluator_Constants(t *testing.T) {
+	assert.NoError(t, constants.LoadDefault())
+	Vars = make(map[string]units.Quantity)
+
+	for _, tt := range []struct {
+		input    string
+		expected float64
+		unit     string
+	}{
+		{"pi", math.Pi, ""},
+		{"math.pi", math.Pi, ""},
+		{"2 π", 2 * math.Pi, ""},
+		{"phys.c", 299792458, "m/s"},
+		{"c", 299792458, "m/s"},
+		{"phys.c * 2 s in km", 599584.916, "km"},
+		{"hbar * 2 pi in J*s", 6.62607015e-34, "J*s"},
+		{"phys.me * phys.c^2 in keV", 510.99895, "keV"},
+		{"phys.R * 300 K / phys.atm in L/mol", 24.6172098, "L/mol"},
+		{"phys.alpha", 7.2973525643e-3, ""},
+		{"sqrt(c)", math.Sqrt(299792458), ""},
+		{"log10(h)", math.Log10(6.62607015e-34), ""},
+		{"max(G, 1)", 1, ""},
+		{"2*deg", 2, "deg"},
+		{"2 deg", 2, "deg"},
+		{"math.deg", 180 / math.Pi, ""},
+		{"90 * math.rad", math.Pi / 2, ""},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := evalQuantityString(t, tt.input)
+			assert.NoError(t, err)
+			value, unit := q.Display()
+			assert.InEpsilon(t, tt.expected, value, 1e-6)
+			assert.Equal(t, tt.unit, unit)
+		})
+	}
+
+	// Only a constant given directly loses its unit
+	_, err := evalQuantityString(t, "sqrt(2*c)")
+	var unitErr *units.UnitError
+	assert.ErrorAs(t, err, &unitErr)
+
+	// Every catalog unit must be a valid unit expression
+	for _, c := range constants.Catalog {
+		_, err := constantQuantity(c)
+		assert.NoError(t, err, "constant %s", c.Key())
+	}
+
+	assert.NotEmpty(t, constants.Search("planck"))
+	found, ok := constants.Get("phys.G")
+	assert.True(t, ok)
+	assert.Equal(t, "CODATA 2022", found.Source)
+	assert.Equal(t, 1.5e-15, found.Uncertainty)
+}
+
+func TestEvaluator_ReadOnly(t *testing.T) {
+	assert.NoError(t, constants.LoadDefault())
+	Vars = make(map[string]units.Quantity)
+	ReadOnly = make(map[string]bool)
+	t.Cleanup(func() {
+		Vars = make(map[string]units.Quantity)
+		ReadOnly = make(map[string]bool)
+	})
+
+	// Built-in constants cannot be reassigned without force
+	_, err := evalQuantityString(t, "pi = 3")
+	assert.ErrorContains(t, err, "cannot assign to built-in constant pi")
+	q, err := evalQuantityString(t, "pi")
+	assert.NoError(t, err)
+	assert.Equal(t, math.Pi, q.Value)
+
+	q, err = evalQuantityString(t, "force pi = 3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, q.Value)
+	q, _ = evalQuantityString(t, "2 pi")
+	assert.Equal(t, 6.0, q.Value)
+	assert.Equal(t, "constant math.pi", Shadowed("pi"))
+
+	// const declarations are read-only until forced
+	_, err = evalQuantityString(t, "const g0 = 9.8")
+	assert.ErrorContains(t, err, "built-in constant")
+	_, err = evalQuantityString(t, "const limit = 10 km")
+	assert.NoError(t, err)
+	assert.True(t, ReadOnly["limit"])
+	_, err = evalQuantityString(t, "limit = 5")
+	assert.ErrorContains(t, err, "cannot assign to constant limit")
+	_, err = evalQuantityString(t, "const limit = 5")
+	assert.Error(t, err)
+	_, err = evalQuantityString(t, "force limit = 5")
+	assert.NoError(t, err)
+	assert.False(t, ReadOnly["limit"])
+	assert.Equal(t, "", Shadowed("limit"))
+
+	// Ordinary names are unaffected; unit names may be shadowed
+	_, err = evalQuantityString(t, "m = 2")
+	assert.NoError(t, err)
+	assert.Equal(t, "unit meter", Shadowed("m"))
+
+	// Command names are never assignable, not even with force
+	Commands = []string{"set", "load"}
+	t.Cleanup(func() { Commands = nil })
+	for _, input := range []string{"set = 3", "const load = 1", "force set = 3"} {
+		_, err = evalQuantityString(t, input)
+		assert.ErrorContains(t, err, "is a command name", input)
+	}
+	_, err = evalQuantityString(t, "settings = 3")
+	assert.NoError(t, err)
+}
+
+func TestEvaluator_ResultReferences(t *testing.T) {
+	Vars = make(map[string]units.Quantity)
+	Results = nil
+	history.File = filepath.Join(t.TempDir(), "history.json")
+	t.Cleanup(func() {
+		Vars = make(map[string]units.Quantity)
+		Results = nil
+	})
+
+	_, err := evalQuantityString(t, "ans")
+	assert.ErrorContains(t, err, "there is no result yet")
+
+	assert.Equal(t, 1, Record(units.Scalar(6)))
+	q, err := evalQuantityString(t, "5 km")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Record(q))
+
+	for _, tt := range []struct {
+		input    string
+		expected float64
+	}{
+		{"ans", 5000},
+		{"_ / 1000", 5},
+		{"$1 * 7", 42},
+		{"$2 + 500 m", 5500},
+		{"2 $1", 12},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := evalQuantityString(t, tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, q.Value)
+		})
+	}
+
+	_, err = evalQuantityString(t, "$3")
+	assert.ErrorContains(t, err, "results go from $1 to $2")
+	_, err = evalQuantityString(t, "ans = 4")
+	assert.ErrorContains(t, err, "cannot assign to ans")
+	_, err = evalQuantityString(t, "force $1 = 4")
+	assert.Error(t, err)
+
+	// History entries are numbered oldest first
+	assert.NoError(t, history.AddHistory("2 + 2", 4, ""))
+	assert.NoError(t, history.AddHistory("3 * 3", 9, ""))
+	q, err = evalQuantityString(t, "@h2 - @h1")
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, q.Value)
+	_, err = evalQuantityString(t, "@h3")
+	assert.ErrorContains(t, err, "history has 2 entries")
+
+	// Entries keep the unit they were shown in
+	assert.NoError(t, history.AddHistory("60 mi / 2 h in km/h", 48.2802, "km/h"))
+	assert.NoError(t, history.AddHistory("20 C", 20, "C"))
+	q, err = evalQuantityString(t, "@h3")
+	assert.NoError(t, err)
+	value, unit := q.Display()
+	assert.InDelta(t, 48.2802, value, 1e-9)
+	assert.Equal(t, "km/h", unit)
+	q, err = evalQuantityString(t, "@h4 + 5 deltaC")
+	assert.NoError(t, err)
+	value, unit = q.Display()
+	assert.InDelta(t, 25, value, 1e-9)
+	assert.Equal(t, "C", unit)
+	_, err = evalQuantityString(t, "@h4 * 2")
+	assert.Error(t, err)
+}
+
+func TestEvaluator_AngleMode(t *testing.T) {
+	t.Cleanup(func() { settings.AngleMode = "deg" })
+	settings.AngleMode = "rad"
+
+	for _, tt := range []struct {
+		input    string
+		expected float64
+	}{
+		{"sin(pi/2)", 1},
+		{"cos(0)", 1},
+		{"asin(1)", math.Pi / 2},
+		{"atan(1)", math.Pi / 4},
+		{"atan2(1, -1)", 3 * math.Pi / 4},
+		{"sin(90 deg)", 1},
+		{"tan(45 deg)", 1},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := evalString(t, tt.input)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-12)
+		})
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	for _, name := range tokenizer.Functions() {
+		signature, ok := Signatures[name]
+		if assert.True(t, ok, "missing signature for %s", name) {
+			assert.Contains(t, signature, name+"(")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Result References
+=================
+Part of Axion CLI Calculator
+
+Earlier results can be used in expressions without retyping them:
+
+    ans, _     the last successful result
+    $3         the third result of the session, shown as [3]: in the REPL
+    @h12       entry 12 of the stored history, as numbered by the history
+               command, in the unit it was shown in
+
+The front ends record each successful result with Record. References are
+resolved before variables and constants, and cannot be assigned to.
+*/
+
+package evaluator
+
+import (
+	"Axion/history"
+	"Axion/units"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Results holds the results recorded this session; $n is Results[n-1]
+var Results []units.Quantity
+
+// Record stores a successful result as ans and the next $n, and returns n
+func Record(q units.Quantity) int {
+	Results = append(Results, q)
+	return len(Results)
+}
+
+// IsReference reports whether name refers to an earlier result
+func IsReference(name string) bool {
+	return name == "ans" || name == "_" || strings.HasPrefix(name, "$") || strings.HasPrefix(name, "@h")
+}
+
+// reference returns the result that name refers to
+func reference(name string) (units.Quantity, error) {
+	switch {
+	case name == "ans" || name == "_":
+		if len(Results) == 0 {
+			return units.Quantity{}, fmt.Errorf("%s is undefined: there is no result yet", name)
+		}
+		return Results[len(Results)-1], nil
+
+	case strings.HasPrefix(name, "$"):
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n < 1 || n > len(Results) {
+			return units.Quantity{}, fmt.Errorf("no result %s (%s)", name, resultRange())
+		}
+		return Results[n-1], nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(name, "@h"))
+	if err != nil {
+		return units.Quantity{}, fmt.Errorf("invalid history reference %s", name)
+	}
+	entries, err := history.Load()
+	if err != nil {
+		return units.Quantity{}, fmt.Errorf("cannot read history: %v", err)
+	}
+	if n < 1 || n > len(entries) {
+		return units.Quantity{}, fmt.Errorf("no history entry %s (history has %d entries)", name, len(entries))
+	}
+	return historyQuantity(name, entries[n-1])
+}
+
+// historyQuantity restores a history entry's result in its stored unit
+func historyQuantity(name string, entry history.Entry) (units.Quantity, error) {
+	value := units.Scalar(float64(entry.Result))
+	if entry.Unit == "" {
+		return value, nil
+	}
+	u, err := units.Parse(entry.Unit)
+	if err != nil {
+		return units.Quantity{}, units.Errorf("cannot restore %s in %s: %v", name, entry.Unit, err)
+	}
+	return u.Reading(value)
+}
+
+// resultRange describes the $n references available
+func resultRange() string {
+	switch len(Results) {
+	case 0:
+		return "there are no results yet"
+	case 1:
+		return "only $1 exists"
+	}
+	return fmt.Sprintf("results go from $1 to $%d", len(Results))
+}
@@ -0,0 +1,52 @@
+/*
+Function Signatures
+===================
+Part of Axion CLI Calculator
+
+Call signatures of the built-in functions, shown by the REPL while the
+cursor is inside a call. Optional arguments are in brackets and "..."
+marks functions taking any number of arguments.
+*/
+package evaluator
+
+// Signatures maps each built-in function to its call signature
+var Signatures = map[string]string{
+	"sin":        "sin(angle)",
+	"cos":        "cos(angle)",
+	"tan":        "tan(angle)",
+	"asin":       "asin(x)",
+	"acos":       "acos(x)",
+	"atan":       "atan(x)",
+	"atan2":      "atan2(y, x)",
+	"log":        "log(x[, base])",
+	"log10":      "log10(x)",
+	"log2":       "log2(x)",
+	"ln":         "ln(x)",
+	"sqrt":       "sqrt(x)",
+	"exp":        "exp(x)",
+	"pow":        "pow(base, exponent)",
+	"abs":        "abs(x)",
+	"ceil":       "ceil(x)",
+	"floor":      "floor(x)",
+	"round":      "round(x)",
+	"trunc":      "trunc(x)",
+	"sign":       "sign(x)",
+	"mod":        "mod(a, b)",
+	"deg2rad":    "deg2rad(degrees)",
+	"rad2deg":    "rad2deg(radians)",
+	"max":        "max(a, b)",
+	"min":        "min(a, b)",
+	"mean":       "mean(x, ...)",
+	"median":     "median(x, ...)",
+	"mode":       "mode(x, ...)",
+	"sum":        "sum(x, ...)",
+	"product":    "product(x, ...)",
+	"rand":       "rand()",
+	"randint":    "randint(a, b)",
+	"randn":      "randn([mu, sigma])",
+	"choice":     "choice(x, ...)",
+	"simulate":   "simulate(expression, n)",
+	"print":      "print(x, ...)",
+	"derivative": "derivative(expression, point)",
+	"fib":        "fib(n)",
+}
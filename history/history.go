@@ -8,18 +8,22 @@ can be displayed to the user for reference.
 
 The history system:
 - Automatically saves each successful calculation
+- Keeps at most settings.HistoryLimit entries, dropping the oldest
 - Persists data across program sessions
-- Displays results in reverse chronological order (newest first)
+- Displays results in reverse chronological order (newest first), numbered
+  oldest first so that @h12 recalls entry 12 in expressions
 - Handles file I/O errors gracefully
 - Uses structured JSON format for data integrity
 
 File format: Array of Entry objects in JSON format
-Location: history.json in the current working directory
+Location: history.json in the data directory (see the paths module)
 */
 
 package history
 
 import (
+	"Axion/paths"
+	"Axion/settings"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -27,6 +31,18 @@ import (
 	"os"
 )
 
+// File is the path of the history file
+var File = historyPath()
+
+// historyPath places history.json in the data directory, falling back to
+// the working directory when no data directory is available
+func historyPath() string {
+	if file := paths.Data("history.json"); file != "" {
+		return file
+	}
+	return "history.json"
+}
+
 // Entry represents a single calculation record in the history
 type JsonFloat float64
 
@@ -45,23 +61,52 @@ func (f JsonFloat) MarshalJSON() ([]byte, error) {
 	return json.Marshal(v)
 }
 
+// UnmarshalJSON reads numbers as well as the "+∞", "-∞" and "NaN" strings
+// written by MarshalJSON
+func (f *JsonFloat) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		switch text {
+		case "+∞":
+			*f = JsonFloat(math.Inf(1))
+		case "-∞":
+			*f = JsonFloat(math.Inf(-1))
+		case "NaN":
+			*f = JsonFloat(math.NaN())
+		default:
+			return fmt.Errorf("invalid result %q", text)
+		}
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*f = JsonFloat(v)
+	return nil
+}
+
 type Entry struct {
-	Expression string    `json:"expression"` // Original mathematical expression
-	Result     JsonFloat `json:"result"`     // Computed numerical result
+	Expression string    `json:"expression"`     // Original mathematical expression
+	Result     JsonFloat `json:"result"`         // Computed result, in Unit
+	Unit       string    `json:"unit,omitempty"` // Unit the result was shown in; empty for plain numbers
 }
 
 // AddHistory appends a new calculation to the persistent history file
-// Handles file creation, existing data preservation, and atomic updates
-func AddHistory(input string, result float64) error {
-	const HistoryFile = "history.json"
+// Handles file creation, existing data preservation, and atomic updates;
+// result and unit are the value and unit shown to the user
+func AddHistory(input string, result float64, unit string) error {
 	var history []Entry
 
 	// Attempt to read existing history data
-	data, err := os.ReadFile(HistoryFile)
+	data, err := os.ReadFile(File)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// File doesn't exist, create it
-			newFile, err := os.Create(HistoryFile)
+			// File doesn't exist, create it along with its directory
+			if err := paths.Prepare(File); err != nil {
+				return err
+			}
+			newFile, err := os.Create(File)
 			if err != nil {
 				return err
 			}
@@ -82,10 +127,14 @@ func AddHistory(input string, result float64) error {
 	}
 
 	// Create new history entry
-	entry := Entry{Expression: input, Result: JsonFloat(result)}
+	entry := Entry{Expression: input, Result: JsonFloat(result), Unit: unit}
 
-	// Append new entry to existing history
+	// Append new entry to existing history, dropping the oldest entries
+	// beyond the configured limit
 	history = append(history, entry)
+	if settings.HistoryLimit > 0 && len(history) > settings.HistoryLimit {
+		history = history[len(history)-settings.HistoryLimit:]
+	}
 
 	// Serialize updated history with readable formatting (no HTML escaping)
 	buffer := &bytes.Buffer{}
@@ -99,7 +148,7 @@ func AddHistory(input string, result float64) error {
 	}
 
 	// Write updated history to file with appropriate permissions
-	err = os.WriteFile(HistoryFile, buffer.Bytes(), 0644)
+	err = os.WriteFile(File, buffer.Bytes(), 0644)
 	if err != nil {
 		return err
 	}
@@ -107,27 +156,11 @@ func AddHistory(input string, result float64) error {
 	return nil
 }
 // ShowHistory displays the complete calculation history in reverse order
-// Most recent calculations are shown first for better user experience
-func ShowHistory() error {
-	const historyFile = "history.json"
-	var history []Entry
-
-	// Read history file
-	data, err := os.ReadFile(historyFile)
+// Most recent calculations are shown first for better user experience;
+// format writes each result and its unit in the user's display mode
+func ShowHistory(format func(value float64, unit string) string) error {
+	history, err := Load()
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Handle case where no history exists yet
-			fmt.Println("no history data")
-			return nil
-		}
-		// Return error for read failures
-		return err
-	}
-
-	// Parse JSON history data
-	err = json.Unmarshal(data, &history)
-	if err != nil {
-		// Return error for malformed JSON
 		return err
 	}
 
@@ -141,10 +174,37 @@ func ShowHistory() error {
 	for i := len(history) - 1; i >= 0; i-- {
 		entry := history[i]
 		fmt.Printf("------------------------------------------------\n")
+		fmt.Printf(" Entry      : @h%d\n", i+1)
 		fmt.Printf(" Expression : %s\n", entry.Expression)
-		fmt.Printf(" Result     : %g\n", entry.Result)
+		fmt.Printf(" Result     : %s\n", format(float64(entry.Result), entry.Unit))
 		fmt.Printf("------------------------------------------------\n\n")
 	}
 
 	return nil
 }
+
+// Load reads the stored history, oldest entry first; a missing file is an
+// empty history
+func Load() ([]Entry, error) {
+	var history []Entry
+
+	// Read history file
+	data, err := os.ReadFile(File)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Handle case where no history exists yet
+			return nil, nil
+		}
+		// Return error for read failures
+		return nil, err
+	}
+
+	// Parse JSON history data
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &history); err != nil {
+			// Return error for malformed JSON
+			return nil, err
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,146 @@
+/*
+Line Buffer - Editing Operations
+================================
+Part of Axion CLI Calculator
+
+The text being edited and the cursor position, with the Emacs-style editing
+operations bound by the editor. Text removed by the kill commands (Ctrl-K,
+Ctrl-U, Ctrl-W, Alt-D) can be yanked back with Ctrl-Y. Words are runs of
+letters, digits and underscores, so "sqrt(x_1)" holds the words sqrt and x_1.
+*/
+
+package lineedit
+
+import "unicode"
+
+// buffer holds the line being edited
+type buffer struct {
+	line   []rune
+	pos    int    // Cursor position, 0 <= pos <= len(line)
+	killed []rune // Text removed by the last kill command
+}
+
+// set replaces the line and moves the cursor to its end
+func (b *buffer) set(s string) {
+	b.line = []rune(s)
+	b.pos = len(b.line)
+}
+
+// insert types runes at the cursor
+func (b *buffer) insert(runes ...rune) {
+	line := make([]rune, 0, len(b.line)+len(runes))
+	line = append(line, b.line[:b.pos]...)
+	line = append(line, runes...)
+	b.line = append(line, b.line[b.pos:]...)
+	b.pos += len(runes)
+}
+
+// remove deletes the runes [start, end) and returns them
+func (b *buffer) remove(start, end int) []rune {
+	removed := append([]rune(nil), b.line[start:end]...)
+	b.line = append(b.line[:start], b.line[end:]...)
+	if b.pos > end {
+		b.pos -= end - start
+	} else if b.pos > start {
+		b.pos = start
+	}
+	return removed
+}
+
+// kill removes [start, end) and keeps it for yank
+func (b *buffer) kill(start, end int) {
+	if start < end {
+		b.killed = b.remove(start, end)
+	}
+}
+
+func (b *buffer) left() {
+	if b.pos > 0 {
+		b.pos--
+	}
+}
+
+func (b *buffer) right() {
+	if b.pos < len(b.line) {
+		b.pos++
+	}
+}
+
+func (b *buffer) home() { b.pos = 0 }
+
+func (b *buffer) end() { b.pos = len(b.line) }
+
+// backspace deletes the rune before the cursor
+func (b *buffer) backspace() {
+	if b.pos > 0 {
+		b.remove(b.pos-1, b.pos)
+	}
+}
+
+// del deletes the rune under the cursor
+func (b *buffer) del() {
+	if b.pos < len(b.line) {
+		b.remove(b.pos, b.pos+1)
+	}
+}
+
+// wordStart returns the start of the word before the cursor
+func (b *buffer) wordStart() int {
+	i := b.pos
+	for i > 0 && !isWordRune(b.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(b.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns the end of the word after the cursor
+func (b *buffer) wordEnd() int {
+	i := b.pos
+	for i < len(b.line) && !isWordRune(b.line[i]) {
+		i++
+	}
+	for i < len(b.line) && isWordRune(b.line[i]) {
+		i++
+	}
+	return i
+}
+
+func (b *buffer) wordLeft() { b.pos = b.wordStart() }
+
+func (b *buffer) wordRight() { b.pos = b.wordEnd() }
+
+// killEnd removes the text from the cursor to the end of the line (Ctrl-K)
+func (b *buffer) killEnd() { b.kill(b.pos, len(b.line)) }
+
+// killStart removes the text before the cursor (Ctrl-U)
+func (b *buffer) killStart() { b.kill(0, b.pos) }
+
+// killWordBack removes the word before the cursor (Ctrl-W)
+func (b *buffer) killWordBack() { b.kill(b.wordStart(), b.pos) }
+
+// killWordForward removes the word after the cursor (Alt-D)
+func (b *buffer) killWordForward() { b.kill(b.pos, b.wordEnd()) }
+
+// yank inserts the last killed text (Ctrl-Y)
+func (b *buffer) yank() { b.insert(b.killed...) }
+
+// transpose swaps the runes before and under the cursor, or the last two
+// runes at the end of the line (Ctrl-T)
+func (b *buffer) transpose() {
+	if len(b.line) < 2 || b.pos == 0 {
+		return
+	}
+	if b.pos == len(b.line) {
+		b.pos--
+	}
+	b.line[b.pos-1], b.line[b.pos] = b.line[b.pos], b.line[b.pos-1]
+	b.pos++
+}
+
+// isWordRune reports whether r is part of a word for word movement
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,644 @@
+/*
+Line Editor Module - Interactive Input
+======================================
+Part of Axion CLI Calculator
+
+This module reads REPL input with line editing. When stdin and stdout are
+terminals it puts the terminal in raw mode while a line is read and binds
+the usual Emacs keys:
+
+    Ctrl-A / Home       start of line       Ctrl-E / End        end of line
+    Ctrl-B / Left       back one character  Ctrl-F / Right      forward one
+    Alt-B / Ctrl-Left   back one word       Alt-F / Ctrl-Right  forward one word
+    Backspace / Ctrl-H  delete backward     Delete              delete forward
+    Ctrl-K              kill to end         Ctrl-U              kill to start
+    Ctrl-W              kill word back      Alt-D               kill word forward
+    Ctrl-Y              yank killed text    Ctrl-T              transpose characters
+    Ctrl-P / Up         previous entry      Ctrl-N / Down       next entry
+    Ctrl-R              search history      Ctrl-L              clear screen
+    Ctrl-C              discard the line    Ctrl-D              delete, or end input
+                                                                on an empty line
+    Tab                 complete the word before the cursor
+
+Ctrl-R starts an incremental search backwards through the history: typed
+characters narrow the match, Ctrl-R again finds an older match, Enter runs
+the match, Ctrl-G restores the line and any other key accepts the match for
+editing. Lines longer than the terminal wrap onto the following rows.
+
+Tab asks the Complete function for candidates: a single candidate is
+inserted, several are completed to their common prefix, and pressing Tab
+again lists them below the line together with the completion's hint (such
+as the signature of the function being called). Highlight, when set,
+decorates the line with colors each time it is redrawn.
+
+Text pasted into a terminal that supports bracketed paste is inserted as
+typed, except that a paste of several lines enters the line and returns
+the whole block, lines separated by '\n', for the caller to run.
+
+When stdin or stdout is not a terminal the editor falls back to reading
+plain lines, so pipes and dumb terminals keep working.
+*/
+
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupt = errors.New("interrupted")
+
+// Special keys decoded from escape sequences, as negative runes
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyWordLeft
+	keyWordRight
+	keyKillWordForward
+	keyKillWordBack
+	keyPasteStart
+	keyUnknown
+)
+
+// Bracketed paste: the terminal wraps pasted text in these sequences
+const (
+	pasteOn  = "\033[?2004h"
+	pasteOff = "\033[?2004l"
+	pasteEnd = "\033[201~"
+)
+
+// Completion lists the candidates for the text between Start and the cursor
+type Completion struct {
+	Start      int      // Start of the text being completed
+	Candidates []string // Replacements for line[Start:cursor]
+	Hint       string   // Shown with the candidates, e.g. a function signature
+}
+
+// maxListed caps the candidates listed at once
+const maxListed = 120
+
+// Editor reads lines from the terminal with editing and history
+type Editor struct {
+	// Complete returns the completions at pos in line; nil disables Tab
+	Complete func(line []rune, pos int) Completion
+
+	// Highlight returns line decorated with ANSI colors for the cursor at
+	// pos (-1 once the line is entered); the visible text must not change
+	Highlight func(line []rune, pos int) string
+
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int
+	history []string // Entries, oldest first
+	index   int      // History entry being edited; len(history) for a new line
+	draft   string   // New line saved while browsing the history
+	pending rune     // Key read during a search, handled next
+	hasKey  bool     // Whether pending holds a key
+	row     int      // Row of the cursor below the first row of the input
+}
+
+// New returns an editor reading stdin and writing stdout
+func New() *Editor {
+	return &Editor{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stdout,
+		fd:  int(os.Stdin.Fd()),
+	}
+}
+
+// Interactive reports whether lines are read with editing, i.e. whether
+// stdin and stdout are terminals
+func (e *Editor) Interactive() bool {
+	return term.IsTerminal(e.fd) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// SetHistory replaces the entries recalled with Up and Ctrl-R, oldest first
+func (e *Editor) SetHistory(entries []string) {
+	e.history = nil
+	for _, entry := range entries {
+		e.AddHistory(entry)
+	}
+}
+
+// AddHistory appends an entry, skipping blanks and repeats of the last entry
+func (e *Editor) AddHistory(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || strings.Contains(entry, "\n") {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == entry {
+		return
+	}
+	e.history = append(e.history, entry)
+}
+
+// ReadLine shows prompt and returns the line entered, without the newline,
+// or a pasted block of lines; it returns io.EOF at the end of input and
+// ErrInterrupt on Ctrl-C
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.Interactive() {
+		return e.readPlain(prompt)
+	}
+
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer term.Restore(e.fd, state)
+	fmt.Fprint(e.out, pasteOn)
+	defer fmt.Fprint(e.out, pasteOff)
+
+	b := &buffer{}
+	e.index, e.draft, e.row = len(e.history), "", 0
+	e.render(prompt, b.line, b.pos)
+
+	for {
+		r, err := e.readKey()
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			e.submit(prompt, b)
+			return string(b.line), nil
+		case ctrl('C'):
+			b.end()
+			e.render(prompt, b.line, b.pos)
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupt
+		case ctrl('D'):
+			if len(b.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			b.del()
+		case ctrl('R'):
+			if e.search(b) {
+				e.submit(prompt, b)
+				return string(b.line), nil
+			}
+		case ctrl('L'):
+			fmt.Fprint(e.out, "\033[H\033[2J")
+			e.row = 0
+		case '\t':
+			e.complete(prompt, b)
+		case keyPasteStart:
+			text, err := e.readPaste()
+			if err != nil {
+				fmt.Fprint(e.out, "\r\n")
+				return "", err
+			}
+			lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+			b.insert([]rune(lines[0])...)
+			if len(lines) > 1 {
+				// A block: enter the first line and echo the others
+				e.submit(prompt, b)
+				for _, line := range lines[1:] {
+					fmt.Fprint(e.out, line+"\r\n")
+				}
+				return string(b.line) + "\n" + strings.Join(lines[1:], "\n"), nil
+			}
+		default:
+			e.edit(b, r)
+		}
+		e.render(prompt, b.line, b.pos)
+	}
+}
+
+// edit applies an editing or history key to b
+func (e *Editor) edit(b *buffer, r rune) {
+	switch r {
+	case ctrl('A'), keyHome:
+		b.home()
+	case ctrl('E'), keyEnd:
+		b.end()
+	case ctrl('B'), keyLeft:
+		b.left()
+	case ctrl('F'), keyRight:
+		b.right()
+	case keyWordLeft:
+		b.wordLeft()
+	case keyWordRight:
+		b.wordRight()
+	case 0x7f, ctrl('H'):
+		b.backspace()
+	case keyDelete:
+		b.del()
+	case ctrl('K'):
+		b.killEnd()
+	case ctrl('U'):
+		b.killStart()
+	case ctrl('W'), keyKillWordBack:
+		b.killWordBack()
+	case keyKillWordForward:
+		b.killWordForward()
+	case ctrl('Y'):
+		b.yank()
+	case ctrl('T'):
+		b.transpose()
+	case ctrl('P'), keyUp:
+		e.previous(b)
+	case ctrl('N'), keyDown:
+		e.next(b)
+	default:
+		if r > 0 && unicode.IsPrint(r) {
+			b.insert(r)
+		}
+	}
+}
+
+// complete inserts the completion at the cursor, or lists the candidates
+// when there is no single completion to insert
+func (e *Editor) complete(prompt string, b *buffer) {
+	if e.Complete == nil {
+		return
+	}
+	c := e.Complete(b.line, b.pos)
+	typed := len(b.line[c.Start:b.pos])
+	if common := commonPrefix(c.Candidates); len([]rune(common)) > typed || len(c.Candidates) == 1 {
+		b.remove(c.Start, b.pos)
+		b.insert([]rune(common)...)
+		return
+	}
+	if len(c.Candidates) == 0 && c.Hint == "" {
+		return
+	}
+
+	// List below the input, then redraw the prompt on a fresh row
+	e.render(prompt, b.line, len(b.line))
+	fmt.Fprint(e.out, "\r\n")
+	if c.Hint != "" {
+		fmt.Fprint(e.out, c.Hint+"\r\n")
+	}
+	e.list(c.Candidates)
+	e.row = 0
+}
+
+// list prints candidates in columns across the terminal
+func (e *Editor) list(candidates []string) {
+	if len(candidates) == 0 {
+		return
+	}
+	more := 0
+	if len(candidates) > maxListed {
+		more = len(candidates) - maxListed
+		candidates = candidates[:maxListed]
+	}
+
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+	column := 0
+	for _, c := range candidates {
+		if n := visibleWidth(c) + 2; n > column {
+			column = n
+		}
+	}
+	perRow := width / column
+	if perRow < 1 {
+		perRow = 1
+	}
+	for i, c := range candidates {
+		fmt.Fprint(e.out, c)
+		if (i+1)%perRow == 0 || i == len(candidates)-1 {
+			fmt.Fprint(e.out, "\r\n")
+		} else {
+			fmt.Fprint(e.out, strings.Repeat(" ", column-visibleWidth(c)))
+		}
+	}
+	if more > 0 {
+		fmt.Fprintf(e.out, "... and %d more\r\n", more)
+	}
+}
+
+// commonPrefix returns the longest prefix shared by all candidates
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	prefix := []rune(candidates[0])
+	for _, c := range candidates[1:] {
+		runes := []rune(c)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
+
+// previous replaces the line with the previous history entry
+func (e *Editor) previous(b *buffer) {
+	if e.index == 0 {
+		return
+	}
+	if e.index == len(e.history) {
+		e.draft = string(b.line)
+	}
+	e.index--
+	b.set(e.history[e.index])
+}
+
+// next replaces the line with the next history entry, or the draft after
+// the newest entry
+func (e *Editor) next(b *buffer) {
+	if e.index >= len(e.history) {
+		return
+	}
+	e.index++
+	if e.index == len(e.history) {
+		b.set(e.draft)
+		return
+	}
+	b.set(e.history[e.index])
+}
+
+// find returns the newest history entry at or before index containing
+// query, and the position of the match in it; index is -1 if none matches
+func (e *Editor) find(query string, index int) (int, int) {
+	if index >= len(e.history) {
+		index = len(e.history) - 1
+	}
+	for i := index; i >= 0; i-- {
+		if at := strings.Index(e.history[i], query); at >= 0 {
+			return i, len([]rune(e.history[i][:at]))
+		}
+	}
+	return -1, 0
+}
+
+// search runs a Ctrl-R incremental search, leaving the accepted match in
+// b; it reports whether Enter was pressed to run the match
+func (e *Editor) search(b *buffer) bool {
+	original := string(b.line)
+	var query []rune
+	index, at := len(e.history), 0
+	failed := false
+
+	for {
+		label := "(reverse-i-search)`"
+		if failed {
+			label = "(failed reverse-i-search)`"
+		}
+		shown := []rune(original)
+		if index < len(e.history) {
+			shown = []rune(e.history[index])
+		}
+		e.draw(label+string(query)+"': ", string(shown), len(shown), at)
+
+		r, err := e.readKey()
+		if err != nil {
+			b.set(original)
+			return false
+		}
+		switch {
+		case r == ctrl('R'):
+			if len(query) > 0 {
+				failed = !e.narrow(string(query), index-1, &index, &at)
+			}
+		case r == 0x7f || r == ctrl('H'):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				index = len(e.history)
+				if len(query) > 0 {
+					failed = !e.narrow(string(query), index, &index, &at)
+				}
+			}
+		case r == ctrl('G') || r == ctrl('C'):
+			b.set(original)
+			return false
+		case r > 0 && unicode.IsPrint(r):
+			query = append(query, r)
+			failed = !e.narrow(string(query), index, &index, &at)
+		default:
+			if index < len(e.history) {
+				b.set(e.history[index])
+				b.pos = at
+				e.index = index
+			}
+			if r == '\r' || r == '\n' {
+				return true
+			}
+			e.pending, e.hasKey = r, true
+			return false
+		}
+	}
+}
+
+// narrow moves the search to the newest match of query at or before from,
+// keeping the current match when there is none
+func (e *Editor) narrow(query string, from int, index, at *int) bool {
+	i, pos := e.find(query, from)
+	if i < 0 {
+		return false
+	}
+	*index, *at = i, pos
+	return true
+}
+
+// submit redraws the line with the cursor at its end and starts a new row
+func (e *Editor) submit(prompt string, b *buffer) {
+	text := string(b.line)
+	if e.Highlight != nil {
+		text = e.Highlight(b.line, -1)
+	}
+	e.draw(prompt, text, len(b.line), len(b.line))
+	fmt.Fprint(e.out, "\r\n")
+}
+
+// render redraws prompt and line, highlighted, leaving the cursor at pos
+func (e *Editor) render(prompt string, line []rune, pos int) {
+	text := string(line)
+	if e.Highlight != nil {
+		text = e.Highlight(line, pos)
+	}
+	e.draw(prompt, text, len(line), pos)
+}
+
+// draw writes prompt and text, which shows length runes, leaving the cursor
+// at pos; lines wider than the terminal wrap onto the following rows
+func (e *Editor) draw(prompt, text string, length, pos int) {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+
+	var out strings.Builder
+	if e.row > 0 {
+		fmt.Fprintf(&out, "\033[%dA", e.row)
+	}
+	out.WriteString("\r\033[J")
+	out.WriteString(prompt)
+	out.WriteString(text)
+
+	start := visibleWidth(prompt)
+	total := start + length
+	if total > 0 && total%width == 0 {
+		out.WriteString("\r\n") // the terminal wraps only when the next rune arrives
+	}
+	endRow := total / width
+	cursor := start + pos
+	row, col := cursor/width, cursor%width
+	if endRow > row {
+		fmt.Fprintf(&out, "\033[%dA", endRow-row)
+	}
+	out.WriteString("\r")
+	if col > 0 {
+		fmt.Fprintf(&out, "\033[%dC", col)
+	}
+	e.row = row
+	io.WriteString(e.out, out.String())
+}
+
+// readPaste reads pasted text up to the end of the bracketed paste, with
+// line breaks as '\n', tabs as spaces and other control characters dropped
+func (e *Editor) readPaste() (string, error) {
+	var text strings.Builder
+	for !strings.HasSuffix(text.String(), pasteEnd) {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		text.WriteRune(r)
+	}
+
+	pasted := strings.TrimSuffix(text.String(), pasteEnd)
+	pasted = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\t", " ").Replace(pasted)
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, pasted), nil
+}
+
+// readPlain reads a line without editing
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readKey reads one key, decoding escape sequences into special keys
+func (e *Editor) readKey() (rune, error) {
+	if e.hasKey {
+		e.hasKey = false
+		return e.pending, nil
+	}
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != 0x1b {
+		return r, err
+	}
+
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch r {
+	case '[', 'O':
+		return e.readSequence()
+	case 'b', 'B':
+		return keyWordLeft, nil
+	case 'f', 'F':
+		return keyWordRight, nil
+	case 'd', 'D':
+		return keyKillWordForward, nil
+	case 0x7f, ctrl('H'):
+		return keyKillWordBack, nil
+	}
+	return keyUnknown, nil
+}
+
+// readSequence decodes the rest of a CSI sequence such as "\033[1;5C"
+func (e *Editor) readSequence() (rune, error) {
+	var params strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r >= 0x40 && r <= 0x7e {
+			return decodeSequence(params.String(), r), nil
+		}
+		params.WriteRune(r)
+	}
+}
+
+// decodeSequence maps the parameters and final byte of a CSI sequence to a key
+func decodeSequence(params string, final rune) rune {
+	modified := strings.HasSuffix(params, ";5") || strings.HasSuffix(params, ";3")
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		if modified {
+			return keyWordRight
+		}
+		return keyRight
+	case 'D':
+		if modified {
+			return keyWordLeft
+		}
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		case "200":
+			return keyPasteStart
+		}
+	}
+	return keyUnknown
+}
+
+// visibleWidth counts the runes of s that take up space, skipping ANSI
+// color sequences
+func visibleWidth(s string) int {
+	width, escape := 0, false
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = !(r >= 0x40 && r <= 0x7e && r != '[')
+		case r == 0x1b:
+			escape = true
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// ctrl returns the rune sent by Ctrl and a letter
+func ctrl(letter rune) rune {
+	return letter & 0x1f
+}
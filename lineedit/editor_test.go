@@ -0,0 +1,147 @@
+package lineedit
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_Editing(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		pos      int
+		edit     func(b *buffer)
+		wantLine string
+		wantPos  int
+	}{
+		{"insert in middle", "sq(2)", 2, func(b *buffer) { b.insert('r', 't') }, "sqrt(2)", 4},
+		{"backspace", "2+3", 2, (*buffer).backspace, "23", 1},
+		{"backspace at start", "2+3", 0, (*buffer).backspace, "2+3", 0},
+		{"delete", "2+3", 1, (*buffer).del, "23", 1},
+		{"word left", "sqrt(x_1) + y", 8, (*buffer).wordLeft, "sqrt(x_1) + y", 5},
+		{"word right", "sqrt(x_1) + y", 4, (*buffer).wordRight, "sqrt(x_1) + y", 8},
+		{"kill to end", "2 + 3 * 4", 5, (*buffer).killEnd, "2 + 3", 5},
+		{"kill to start", "2 + 3 * 4", 6, (*buffer).killStart, "* 4", 0},
+		{"kill word back", "sin(angle)", 9, (*buffer).killWordBack, "sin()", 4},
+		{"kill word forward", "sin(angle)", 4, (*buffer).killWordForward, "sin()", 4},
+		{"transpose", "12+", 1, (*buffer).transpose, "21+", 2},
+		{"transpose at end", "2+31", 4, (*buffer).transpose, "2+13", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &buffer{line: []rune(tt.line), pos: tt.pos}
+			tt.edit(b)
+
+			assert.Equal(t, tt.wantLine, string(b.line))
+			assert.Equal(t, tt.wantPos, b.pos)
+		})
+	}
+}
+
+func TestBuffer_Yank(t *testing.T) {
+	b := &buffer{}
+	b.set("x = 42")
+	b.killWordBack()
+	b.home()
+	b.yank()
+
+	assert.Equal(t, "42x = ", string(b.line))
+	assert.Equal(t, 2, b.pos)
+}
+
+func TestEditor_History(t *testing.T) {
+	e := &Editor{}
+	e.SetHistory([]string{"1+1", "", "2+2", "2+2", "3+3"})
+	assert.Equal(t, []string{"1+1", "2+2", "3+3"}, e.history)
+
+	b := &buffer{}
+	b.set("draft")
+	e.index = len(e.history)
+
+	e.previous(b)
+	assert.Equal(t, "3+3", string(b.line))
+	e.previous(b)
+	e.previous(b)
+	e.previous(b) // stays at the oldest entry
+	assert.Equal(t, "1+1", string(b.line))
+
+	e.next(b)
+	e.next(b)
+	e.next(b)
+	assert.Equal(t, "draft", string(b.line))
+}
+
+func TestEditor_Find(t *testing.T) {
+	e := &Editor{}
+	e.SetHistory([]string{"sqrt(16)", "x = 3", "sqrt(x)"})
+
+	index, at := e.find("sqrt", len(e.history))
+	assert.Equal(t, 2, index)
+	assert.Equal(t, 0, at)
+
+	index, _ = e.find("sqrt", index-1)
+	assert.Equal(t, 0, index)
+
+	index, at = e.find("3", 2)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, 4, at)
+
+	index, _ = e.find("cos", 2)
+	assert.Equal(t, -1, index)
+}
+
+func TestEditor_ReadKey(t *testing.T) {
+	e := &Editor{in: bufio.NewReader(strings.NewReader("a\033[A\033[1;5C\033[3~\033b\x01"))}
+
+	var keys []rune
+	for {
+		r, err := e.readKey()
+		if err == io.EOF {
+			break
+		}
+		keys = append(keys, r)
+	}
+	assert.Equal(t, []rune{'a', keyUp, keyWordRight, keyDelete, keyWordLeft, ctrl('A')}, keys)
+}
+
+func TestEditor_ReadPaste(t *testing.T) {
+	e := &Editor{in: bufio.NewReader(strings.NewReader("\033[200~x = 1\r\ny\t= 2\x07\ry\033[201~z"))}
+
+	r, err := e.readKey()
+	assert.NoError(t, err)
+	assert.Equal(t, keyPasteStart, r)
+
+	text, err := e.readPaste()
+	assert.NoError(t, err)
+	assert.Equal(t, "x = 1\ny = 2\ny", text)
+
+	r, _ = e.readKey()
+	assert.Equal(t, 'z', r)
+}
+
+func TestEditor_ReadPlain(t *testing.T) {
+	var out strings.Builder
+	e := &Editor{in: bufio.NewReader(strings.NewReader("2+2\r\n3")), out: &out}
+
+	line, err := e.readPlain("» ")
+	assert.NoError(t, err)
+	assert.Equal(t, "2+2", line)
+
+	line, err = e.readPlain("» ")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", line)
+
+	_, err = e.readPlain("» ")
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "» » » ", out.String())
+}
+
+func TestVisibleWidth(t *testing.T) {
+	assert.Equal(t, 2, visibleWidth("\033[36m» \033[0m"))
+	assert.Equal(t, 5, visibleWidth("hello"))
+}
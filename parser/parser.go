@@ -19,17 +19,25 @@ Precedence Hierarchy (lowest to highest):
 1. Assignment operators (=)
 2. Addition and subtraction (+, -)
 3. Multiplication and division (*, /)
-4. Unary operators (-, +)
-5. Exponentiation (^) - right associative
-6. Postfix operators (factorial !)
-7. Primary expressions (numbers, functions, parentheses)
+4. Unit annotations (5 km, 2 h) - bind tighter than * and /
+5. Unary operators (-, +)
+6. Exponentiation (^) - right associative
+7. Postfix operators (factorial !)
+8. Primary expressions (numbers, functions, parentheses)
+
+A trailing "in <unit>" or "to <unit>" converts the whole expression for
+display, e.g. "60 mi / 2 h in km/h".
 
 AST Node Types:
 - NODE_NUMBER: Terminal nodes containing numeric literals
 - NODE_OPERATOR: Binary and unary operation nodes
 - NODE_FUNCTION: Function calls with argument lists
 - NODE_ASSIGN: Variable assignment operations
+- NODE_CONST: Read-only declarations (const g = 9.81)
+- NODE_FORCE: Assignments that may replace a constant (force pi = 3)
 - NODE_IDENTIFIER: Variable and constant references
+- NODE_UNIT: Unit annotations following a number
+- NODE_CONVERT: Display conversion into a target unit expression
 
 Key Features:
 - Operator Precedence: Ensures mathematical correctness (2 + 3 * 4 = 14, not 20)
@@ -52,7 +60,9 @@ package parser
 
 import (
 	"Axion/tokenizer"
+	"Axion/units"
 	"fmt"
+	"strings"
 )
 
 // NodeType categorizes AST node types for evaluation dispatch
@@ -67,6 +77,10 @@ const (
 	NODE_OR
 	NODE_AND
 	NODE_COMPARISON
+	NODE_UNIT    // Unit annotation attached to a number (5 km)
+	NODE_CONVERT // Display conversion of Left into the unit expression Right
+	NODE_CONST   // Read-only declaration (const name = expr)
+	NODE_FORCE   // Assignment allowed to replace a constant (force name = expr)
 )
 
 // Node represents a single node in the Abstract Syntax Tree
@@ -103,7 +117,30 @@ func (p *Parser) ParseExpression() (*Node, error) {
 	return node, nil
 }
 
+// Pos returns the index of the token the parser stopped at, e.g. the token
+// that caused an error (len(Tokens) at the end of input)
+func (p *Parser) Pos() int {
+	return p.pos
+}
+
+// parseAssignment parses "name = expr", optionally preceded by the
+// "const" or "force" keyword, or falls through to an ordinary expression
 func (p *Parser) parseAssignment() (*Node, error) {
+	nodeType := NODE_ASSIGN
+	if p.pos+2 < len(p.Tokens) &&
+		p.Tokens[p.pos].Type == tokenizer.IDENT &&
+		p.Tokens[p.pos+1].Type == tokenizer.IDENT &&
+		p.Tokens[p.pos+2].Type == tokenizer.ASSIGN {
+		switch p.Tokens[p.pos].Value {
+		case "const":
+			nodeType = NODE_CONST
+			p.pos++
+		case "force":
+			nodeType = NODE_FORCE
+			p.pos++
+		}
+	}
+
 	if p.pos+1 < len(p.Tokens) &&
 		p.Tokens[p.pos].Type == tokenizer.IDENT &&
 		p.Tokens[p.pos+1].Type == tokenizer.ASSIGN {
@@ -119,14 +156,70 @@ func (p *Parser) parseAssignment() (*Node, error) {
 			return nil, fmt.Errorf("expected expression after '='")
 		}
 
+		rightNode, err = p.parseConversion(rightNode)
+		if err != nil {
+			return nil, err
+		}
+
 		return &Node{
-			Type:  NODE_ASSIGN,
+			Type:  nodeType,
 			Value: varName,
 			Right: rightNode,
 		}, nil
 	}
 
-	return p.parseLogicalOr() // ← Changed from parseAddSub()
+	node, err := p.parseLogicalOr() // ← Changed from parseAddSub()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseConversion(node)
+}
+
+// isConversionAt reports whether the token at i starts an "in"/"to" conversion
+func (p *Parser) isConversionAt(i int) bool {
+	if i+1 >= len(p.Tokens) {
+		return false
+	}
+	tok, next := p.Tokens[i], p.Tokens[i+1]
+	if tok.Type != tokenizer.IDENT || (tok.Value != "in" && tok.Value != "to") {
+		return false
+	}
+	// "12 in in cm": the first "in" is the inch unit, not the keyword
+	if next.Value == "in" || next.Value == "to" {
+		return false
+	}
+	return next.Type == tokenizer.IDENT || next.Type == tokenizer.FUNCTION || next.Value == "("
+}
+
+// parseConversion wraps node in a NODE_CONVERT when followed by "in"/"to" <unit>
+func (p *Parser) parseConversion(node *Node) (*Node, error) {
+	if p.pos < len(p.Tokens) && p.Tokens[p.pos].Implicit && p.isConversionAt(p.pos+1) {
+		p.pos++ // skip multiplication inserted after ')'
+	}
+	if !p.isConversionAt(p.pos) {
+		return node, nil
+	}
+	p.pos++ // consume 'in' / 'to'
+
+	start := p.pos
+	target, err := p.parseMulDiv()
+	if err != nil {
+		return nil, fmt.Errorf("invalid target unit: %v", err)
+	}
+
+	var label strings.Builder
+	for _, tok := range p.Tokens[start:p.pos] {
+		if !tok.Implicit {
+			label.WriteString(tok.Value)
+		}
+	}
+
+	return &Node{
+		Type:  NODE_CONVERT,
+		Value: label.String(),
+		Left:  node,
+		Right: target,
+	}, nil
 }
 
 func (p *Parser) parseLogicalOr() (*Node, error) {
@@ -248,7 +341,7 @@ func (p *Parser) parseAddSub() (*Node, error) {
 }
 
 func (p *Parser) parseMulDiv() (*Node, error) {
-	node, err := p.parseUnary()
+	node, err := p.parseQuantity()
 	if err != nil {
 		return nil, err
 	}
@@ -258,9 +351,12 @@ func (p *Parser) parseMulDiv() (*Node, error) {
 
 	for p.pos < len(p.Tokens) {
 		tok := p.Tokens[p.pos]
+		if tok.Implicit && p.isConversionAt(p.pos+1) {
+			break
+		}
 		if tok.Type == tokenizer.OPERATOR && (tok.Value == "*" || tok.Value == "/") {
 			p.pos++
-			right, err := p.parseUnary()
+			right, err := p.parseQuantity()
 			if err != nil {
 				return nil, err
 			}
@@ -275,6 +371,46 @@ func (p *Parser) parseMulDiv() (*Node, error) {
 	return node, nil
 }
 
+// parseQuantity binds unit annotations written directly after a value,
+// so that "60 mi / 2 h" divides by the quantity "2 h"
+func (p *Parser) parseQuantity() (*Node, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isUnitJuxtaposition() {
+		p.pos++ // consume implicit '*'
+		unit, err := p.parseExponent()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case unit.Type == NODE_IDENTIFIER:
+			unit.Type = NODE_UNIT
+		case unit.Value == "^" && unit.Left.Type == NODE_IDENTIFIER:
+			unit.Left.Type = NODE_UNIT
+		}
+		node = &Node{Type: NODE_OPERATOR, Value: "*", Left: node, Right: unit}
+	}
+	return node, nil
+}
+
+// isUnitJuxtaposition reports whether an implicit multiplication is followed by a unit name
+func (p *Parser) isUnitJuxtaposition() bool {
+	if p.pos+1 >= len(p.Tokens) || !p.Tokens[p.pos].Implicit {
+		return false
+	}
+	next := p.Tokens[p.pos+1]
+	if next.Type != tokenizer.IDENT && next.Type != tokenizer.FUNCTION {
+		return false
+	}
+	if p.pos+2 < len(p.Tokens) && p.Tokens[p.pos+2].Value == "(" {
+		return false
+	}
+	return units.IsUnit(next.Value) && !p.isConversionAt(p.pos+1)
+}
+
 func (p *Parser) parseUnary() (*Node, error) {
 	if p.pos >= len(p.Tokens) {
 		return nil, fmt.Errorf("unexpected end of expression")
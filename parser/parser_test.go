@@ -335,6 +335,24 @@ func TestParser_Expression(t *testing.T) {
 				Right: &Node{Type: NODE_NUMBER, Value: "1"},
 			},
 		},
+		{
+			name:  "const declaration",
+			input: "const g = 9.81",
+			expected: &Node{
+				Type:  NODE_CONST,
+				Value: "g",
+				Right: &Node{Type: NODE_NUMBER, Value: "9.81"},
+			},
+		},
+		{
+			name:  "forced assignment",
+			input: "force pi = 3",
+			expected: &Node{
+				Type:  NODE_FORCE,
+				Value: "pi",
+				Right: &Node{Type: NODE_NUMBER, Value: "3"},
+			},
+		},
 		{
 			name:  "assignment with expression",
 			input: "r=1+2*3",
@@ -806,3 +824,149 @@ func TestParser_Expression_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_Units(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *Node
+	}{
+		{
+			name:  "unit annotation",
+			input: "5 km",
+			expected: &Node{
+				Type:  NODE_OPERATOR,
+				Value: "*",
+				Left:  &Node{Type: NODE_NUMBER, Value: "5"},
+				Right: &Node{Type: NODE_UNIT, Value: "km"},
+			},
+		},
+		{
+			name:  "unit annotation binds tighter than division",
+			input: "60 mi / 2 h",
+			expected: &Node{
+				Type:  NODE_OPERATOR,
+				Value: "/",
+				Left: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "*",
+					Left:  &Node{Type: NODE_NUMBER, Value: "60"},
+					Right: &Node{Type: NODE_UNIT, Value: "mi"},
+				},
+				Right: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "*",
+					Left:  &Node{Type: NODE_NUMBER, Value: "2"},
+					Right: &Node{Type: NODE_UNIT, Value: "h"},
+				},
+			},
+		},
+		{
+			name:  "unit with exponent",
+			input: "3 m^2",
+			expected: &Node{
+				Type:  NODE_OPERATOR,
+				Value: "*",
+				Left:  &Node{Type: NODE_NUMBER, Value: "3"},
+				Right: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "^",
+					Left:  &Node{Type: NODE_UNIT, Value: "m"},
+					Right: &Node{Type: NODE_NUMBER, Value: "2"},
+				},
+			},
+		},
+		{
+			name:  "non-unit identifier keeps normal precedence",
+			input: "1/2x",
+			expected: &Node{
+				Type:  NODE_OPERATOR,
+				Value: "*",
+				Left: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "/",
+					Left:  &Node{Type: NODE_NUMBER, Value: "1"},
+					Right: &Node{Type: NODE_NUMBER, Value: "2"},
+				},
+				Right: &Node{Type: NODE_IDENTIFIER, Value: "x"},
+			},
+		},
+		{
+			name:  "conversion postfix",
+			input: "x in km/h",
+			expected: &Node{
+				Type:  NODE_CONVERT,
+				Value: "km/h",
+				Left:  &Node{Type: NODE_IDENTIFIER, Value: "x"},
+				Right: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "/",
+					Left:  &Node{Type: NODE_IDENTIFIER, Value: "km"},
+					Right: &Node{Type: NODE_IDENTIFIER, Value: "h"},
+				},
+			},
+		},
+		{
+			name:  "inch unit followed by conversion",
+			input: "12 in in cm",
+			expected: &Node{
+				Type:  NODE_CONVERT,
+				Value: "cm",
+				Left: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "*",
+					Left:  &Node{Type: NODE_NUMBER, Value: "12"},
+					Right: &Node{Type: NODE_UNIT, Value: "in"},
+				},
+				Right: &Node{Type: NODE_IDENTIFIER, Value: "cm"},
+			},
+		},
+		{
+			name:  "conversion after parentheses",
+			input: "(5 km) to m",
+			expected: &Node{
+				Type:  NODE_CONVERT,
+				Value: "m",
+				Left: &Node{
+					Type:  NODE_OPERATOR,
+					Value: "*",
+					Left:  &Node{Type: NODE_NUMBER, Value: "5"},
+					Right: &Node{Type: NODE_UNIT, Value: "km"},
+				},
+				Right: &Node{Type: NODE_IDENTIFIER, Value: "m"},
+			},
+		},
+		{
+			name:  "assignment with conversion",
+			input: "d = 5 km to mi",
+			expected: &Node{
+				Type:  NODE_ASSIGN,
+				Value: "d",
+				Right: &Node{
+					Type:  NODE_CONVERT,
+					Value: "mi",
+					Left: &Node{
+						Type:  NODE_OPERATOR,
+						Value: "*",
+						Left:  &Node{Type: NODE_NUMBER, Value: "5"},
+						Right: &Node{Type: NODE_UNIT, Value: "km"},
+					},
+					Right: &Node{Type: NODE_IDENTIFIER, Value: "mi"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenizer.Tokenize(tt.input)
+			assert.NoError(t, err)
+
+			p := Parser{Tokens: tokens}
+			ast, err := p.ParseExpression()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ast)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Paths Module - Configuration and Data File Locations
+====================================================
+Part of Axion CLI Calculator
+
+This module decides where Axion keeps its files, so the calculator behaves
+the same whichever directory it is started from:
+
+    Config (constants.json, units.json, config.yaml):
+        $AXION_HOME, else $XDG_CONFIG_HOME/axion, else the OS config dir
+        (~/.config/axion, ~/Library/Application Support/axion, %AppData%\axion)
+
+    Data (history.json, rates.json, workspaces/):
+        $AXION_HOME, else $XDG_DATA_HOME/axion, else ~/.local/share/axion
+        (the OS config dir on macOS and Windows)
+
+Setting AXION_HOME keeps everything in one directory, which is handy for
+portable installs and tests.
+*/
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// HomeEnv names the environment variable that overrides both directories
+const HomeEnv = "AXION_HOME"
+
+// appName is the directory name used below the XDG base directories
+const appName = "axion"
+
+// ConfigDir returns the directory holding user configuration
+func ConfigDir() string {
+	if home := os.Getenv(HomeEnv); home != "" {
+		return home
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, appName)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, appName)
+	}
+	return ""
+}
+
+// DataDir returns the directory holding history and imported data
+func DataDir() string {
+	if home := os.Getenv(HomeEnv); home != "" {
+		return home
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, appName)
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return ConfigDir()
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share", appName)
+	}
+	return ""
+}
+
+// Config returns the path of a file in the config directory,
+// or "" if no config directory is available
+func Config(name string) string {
+	dir := ConfigDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// Data returns the path of a file in the data directory,
+// or "" if no data directory is available
+func Data(name string) string {
+	dir := DataDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// Exists reports whether path names an existing file
+func Exists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Prepare creates the parent directories of path
+func Prepare(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0o755)
+}
@@ -1,13 +1,306 @@
+/*
+Settings Module - User Preferences
+==================================
+Part of Axion CLI Calculator
+
+This module holds the calculator's settings as package variables and
+persists them in a YAML config file (config.yaml in the config directory):
+
+    precision: 6          # digits shown: decimal places in fixed mode,
+                          # significant digits otherwise (0-20)
+    angle: deg            # trigonometry in degrees (deg) or radians (rad)
+    display: auto         # number display mode: auto, fixed, sig, sci, eng, si
+    theme: default        # color theme: default, bright or mono
+    locale: plain         # number separators: plain, en, de, fr, ch
+    units: ""             # preferred unit system for auto conversion: metric, imperial
+    history:
+      limit: 1000         # entries kept in history.json (0 = unlimited)
+    rates:
+      max_age: 7          # days before currency rates are reported as stale
+    autosave: false       # save the REPL session on exit, restore it at start
+
+Missing keys keep their defaults, so a config file only needs the settings
+the user changed. Every setting is also addressable by a dotted key
+("history.limit") for the set command and command-line overrides.
+*/
+
 package settings
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
 
 var Precision = 6
 
-func Set(p int) error {
-	if p < 0 || p > 20 {
-		return fmt.Errorf("precision must be between 0 and 20")
+// RatesMaxAge is the age in days after which currency rates are reported as stale
+var RatesMaxAge = 7
+
+// AngleMode selects degrees ("deg") or radians ("rad") for trigonometry
+var AngleMode = "deg"
+
+// DisplayMode selects how numbers are written (see units.Format)
+var DisplayMode = "auto"
+
+// Theme names the color theme of the REPL
+var Theme = "default"
+
+// Locale selects the decimal separator and digit grouping of numbers
+var Locale = "plain"
+
+// UnitSystem is the preferred system for auto conversion ("" keeps the source's)
+var UnitSystem = ""
+
+// HistoryLimit is the number of history entries kept (0 keeps all)
+var HistoryLimit = 1000
+
+// Autosave saves the REPL session on exit and restores it at the next start
+var Autosave = false
+
+// Allowed values of the enumerated settings
+var (
+	AngleModes   = []string{"deg", "rad"}
+	DisplayModes = []string{"auto", "fixed", "sig", "sci", "eng", "si"}
+	Themes       = []string{"default", "bright", "mono"}
+	LocaleNames  = []string{"plain", "en", "de", "fr", "ch"}
+	UnitSystems  = []string{"metric", "imperial"}
+)
+
+// Config is the on-disk form of the settings
+type Config struct {
+	Precision int    `yaml:"precision" json:"precision"`
+	Angle     string `yaml:"angle" json:"angle"`
+	Display   string `yaml:"display" json:"display"`
+	Theme     string `yaml:"theme" json:"theme"`
+	Locale    string `yaml:"locale" json:"locale"`
+	Units     string `yaml:"units" json:"units"`
+	History   struct {
+		Limit int `yaml:"limit" json:"limit"`
+	} `yaml:"history" json:"history"`
+	Rates struct {
+		MaxAge int `yaml:"max_age" json:"max_age"`
+	} `yaml:"rates" json:"rates"`
+	Autosave bool `yaml:"autosave" json:"autosave"`
+}
+
+// option describes one setting addressable by key
+type option struct {
+	key  string
+	help string
+	get  func(c *Config) string
+	set  func(c *Config, value string) error
+}
+
+// options lists every setting in display order
+var options = []option{
+	{"precision", "digits shown (decimal places in fixed mode)",
+		func(c *Config) string { return strconv.Itoa(c.Precision) },
+		func(c *Config, v string) error { return setInt(&c.Precision, v, 0, 20, "precision") }},
+	{"angle", "trigonometry in deg or rad",
+		func(c *Config) string { return c.Angle },
+		func(c *Config, v string) error { return setChoice(&c.Angle, v, AngleModes, "angle") }},
+	{"display", "number display mode (" + strings.Join(DisplayModes, ", ") + ")",
+		func(c *Config) string { return c.Display },
+		func(c *Config, v string) error { return setChoice(&c.Display, v, DisplayModes, "display") }},
+	{"theme", "color theme (" + strings.Join(Themes, ", ") + ")",
+		func(c *Config) string { return c.Theme },
+		func(c *Config, v string) error { return setChoice(&c.Theme, v, Themes, "theme") }},
+	{"locale", "number separators (" + strings.Join(LocaleNames, ", ") + ")",
+		func(c *Config) string { return c.Locale },
+		func(c *Config, v string) error { return setChoice(&c.Locale, v, LocaleNames, "locale") }},
+	{"units", "unit system for auto conversion (metric, imperial, none)",
+		func(c *Config) string { return c.Units },
+		func(c *Config, v string) error {
+			if v == "none" || v == "" {
+				c.Units = ""
+				return nil
+			}
+			return setChoice(&c.Units, v, UnitSystems, "units")
+		}},
+	{"history.limit", "history entries kept (0 = unlimited)",
+		func(c *Config) string { return strconv.Itoa(c.History.Limit) },
+		func(c *Config, v string) error { return setInt(&c.History.Limit, v, 0, -1, "history.limit") }},
+	{"rates.max_age", "days before currency rates are stale",
+		func(c *Config) string { return strconv.Itoa(c.Rates.MaxAge) },
+		func(c *Config, v string) error { return setInt(&c.Rates.MaxAge, v, 0, -1, "rates.max_age") }},
+	{"autosave", "save the REPL session on exit and restore it (on, off)",
+		func(c *Config) string { return onOff(c.Autosave) },
+		func(c *Config, v string) error { return setBool(&c.Autosave, v, "autosave") }},
+}
+
+// Defaults returns the built-in settings
+func Defaults() Config {
+	c := Config{Precision: 6, Angle: "deg", Display: "auto", Theme: "default", Locale: "plain"}
+	c.History.Limit = 1000
+	c.Rates.MaxAge = 7
+	return c
+}
+
+// Current returns the settings in effect
+func Current() Config {
+	c := Config{
+		Precision: Precision,
+		Angle:     AngleMode,
+		Display:   DisplayMode,
+		Theme:     Theme,
+		Locale:    Locale,
+		Units:     UnitSystem,
+	}
+	c.History.Limit = HistoryLimit
+	c.Rates.MaxAge = RatesMaxAge
+	c.Autosave = Autosave
+	return c
+}
+
+// Apply makes c the settings in effect
+func Apply(c Config) {
+	Precision = c.Precision
+	AngleMode = c.Angle
+	DisplayMode = c.Display
+	Theme = c.Theme
+	Locale = c.Locale
+	UnitSystem = c.Units
+	HistoryLimit = c.History.Limit
+	RatesMaxAge = c.Rates.MaxAge
+	Autosave = c.Autosave
+}
+
+// Keys lists the setting keys in display order
+func Keys() []string {
+	keys := make([]string, len(options))
+	for i, o := range options {
+		keys[i] = o.key
+	}
+	return keys
+}
+
+// Help returns the description of a setting
+func Help(key string) string {
+	if o, err := find(key); err == nil {
+		return o.help
+	}
+	return ""
+}
+
+// Get returns the value of a setting as text
+func (c *Config) Get(key string) (string, error) {
+	o, err := find(key)
+	if err != nil {
+		return "", err
+	}
+	return o.get(c), nil
+}
+
+// Set validates value and stores it under key
+func (c *Config) Set(key, value string) error {
+	o, err := find(key)
+	if err != nil {
+		return err
+	}
+	return o.set(c, strings.TrimSpace(value))
+}
+
+// validate checks every setting of c, e.g. after reading a file
+func (c *Config) validate() error {
+	for _, o := range options {
+		if err := o.set(c, o.get(c)); err != nil {
+			return err
+		}
 	}
-	Precision = p
 	return nil
 }
+
+// Read loads a config file; a missing file yields the defaults
+func Read(path string) (Config, error) {
+	c := Defaults()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return Defaults(), fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return Defaults(), fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Write saves c to a config file, creating parent directories
+func Write(path string, c Config) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// find returns the option registered under key
+func find(key string) (option, error) {
+	for _, o := range options {
+		if o.key == strings.ToLower(key) {
+			return o, nil
+		}
+	}
+	return option{}, fmt.Errorf("unknown setting %q (available: %s)", key, strings.Join(Keys(), ", "))
+}
+
+// setInt parses an integer setting within [min, max]; max < 0 means no upper bound
+func setInt(target *int, value string, min, max int, key string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s must be a whole number, got %q", key, value)
+	}
+	if n < min || (max >= 0 && n > max) {
+		if max < 0 {
+			return fmt.Errorf("%s must not be below %d", key, min)
+		}
+		return fmt.Errorf("%s must be between %d and %d", key, min, max)
+	}
+	*target = n
+	return nil
+}
+
+// setChoice stores value if it is one of choices
+func setChoice(target *string, value string, choices []string, key string) error {
+	value = strings.ToLower(value)
+	for _, choice := range choices {
+		if value == choice {
+			*target = value
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(choices, ", "), value)
+}
+
+// setBool parses on/off (or true/false) into target
+func setBool(target *bool, value string, key string) error {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes":
+		*target = true
+	case "off", "false", "no":
+		*target = false
+	default:
+		return fmt.Errorf("%s must be on or off, got %q", key, value)
+	}
+	return nil
+}
+
+// onOff writes a boolean setting as on or off
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -14,9 +14,15 @@ Core Capabilities:
 - Mathematical operator recognition (+, -, *, /, ^, !, =)
 - Function name identification and classification
 - Parentheses and comma handling for grouping and function arguments
-- Intelligent implicit multiplication insertion (2sin(x) → 2 * sin(x))
-- Variable and identifier recognition
+- Intelligent implicit multiplication insertion (2sin(x) → 2 * sin(x)),
+  marked as Implicit so the parser can bind unit annotations (5 km) tightly
+- Variable and identifier recognition, including Unicode unit symbols
+  (µm, Ω, °C, m²), and underscores in names (x_1, _)
+- Multi-word unit names listed in UnitPhrases (fl oz) read as one identifier
+- Result references: $3 for a numbered result, @h12 for a history entry
 - Assignment operator support
+- Decimal comma input (DecimalComma): 1.234,5 reads as 1234.5 and ';'
+  separates function arguments
 
 Key Features:
 - Scientific Notation: Complete support for exponential format including optional signs
@@ -41,6 +47,8 @@ package tokenizer
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"unicode"
 )
 
@@ -57,164 +65,351 @@ const (
 	LOGICAL
 )
 
+// DecimalComma makes ',' the decimal separator inside numbers, '.' a digit
+// grouping mark and ';' the argument separator
+var DecimalComma bool
+
 type Token struct {
-	Type  TokenType
-	Value string
+	Type     TokenType
+	Value    string
+	Implicit bool // Set on multiplication operators inserted by juxtaposition
+}
+
+// Span is the range of runes [Start, End) of the input a token was read from;
+// implicit multiplications have an empty span
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Error is a tokenizer error with the span of the offending input
+type Error struct {
+	Msg  string
+	Span Span
+}
+
+// Error returns the message without the position
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// errorAt returns an Error covering the runes [start, end)
+func errorAt(start, end int, format string, args ...interface{}) *Error {
+	return &Error{Msg: fmt.Sprintf(format, args...), Span: Span{Start: start, End: end}}
 }
 
-func flushBuffers(numberBuffer, wordBuffer *string, addToken func(Token)) {
+func flushBuffers(numberBuffer, wordBuffer *string, numberSpan, wordSpan Span, addToken func(Token, Span)) {
 	if *numberBuffer != "" {
-		addToken(Token{Type: NUMBER, Value: *numberBuffer})
+		addToken(Token{Type: NUMBER, Value: *numberBuffer}, numberSpan)
 		*numberBuffer = ""
 	}
 	if *wordBuffer != "" {
 		if isMathFunction(*wordBuffer) {
-			addToken(Token{Type: FUNCTION, Value: *wordBuffer})
+			addToken(Token{Type: FUNCTION, Value: *wordBuffer}, wordSpan)
 		} else {
-			addToken(Token{Type: IDENT, Value: *wordBuffer})
+			addToken(Token{Type: IDENT, Value: *wordBuffer}, wordSpan)
 		}
 		*wordBuffer = ""
 	}
 }
 
 func Tokenize(input string) ([]Token, error) {
+	tokens, _, err := Scan(input)
+	return tokens, err
+}
+
+// Scan tokenizes input like Tokenize and also returns the span of each
+// token; errors are of type *Error
+func Scan(input string) ([]Token, []Span, error) {
 	var tokens []Token
+	var spans []Span
 	var numberBuffer string
+	chars := []rune(input)
 	var wordBuffer string
+	var numberStart, wordStart int
+	var grouped bool // numberBuffer has passed a grouping mark
 
-	addToken := func(t Token) {
+	addToken := func(t Token, span Span) {
 		if len(tokens) > 0 {
 			last := tokens[len(tokens)-1]
 
 			if t.Type == FUNCTION && t.Value == "!" {
 			} else if (last.Type == NUMBER || (last.Type == PAREN && last.Value == ")")) &&
 				(t.Type == NUMBER || t.Type == FUNCTION || t.Type == IDENT || (t.Type == PAREN && t.Value == "(")) {
-				tokens = append(tokens, Token{Type: OPERATOR, Value: "*"})
+				tokens = append(tokens, Token{Type: OPERATOR, Value: "*", Implicit: true})
+				spans = append(spans, Span{Start: span.Start, End: span.Start})
 			}
 		}
 		tokens = append(tokens, t)
+		spans = append(spans, span)
+	}
+
+	// flush emits the buffered number and word, which end at rune end
+	flush := func(end int) {
+		numberEnd := end
+		if wordBuffer != "" {
+			numberEnd = wordStart
+		}
+		flushBuffers(&numberBuffer, &wordBuffer, Span{numberStart, numberEnd}, Span{wordStart, end}, addToken)
 	}
 
-	for i := 0; i < len(input); i++ {
-		ch := rune(input[i])
+	for i := 0; i < len(chars); i++ {
+		ch := chars[i]
+
+		if DecimalComma && wordBuffer == "" {
+			switch {
+			case ch == ',' && numberBuffer != "":
+				// A decimal comma is followed by a digit; anything else is
+				// an argument separator written the English way
+				if i+1 >= len(chars) || !unicode.IsDigit(chars[i+1]) {
+					return nil, nil, errorAt(i, i+1, "unexpected ',' after %s: ',' is the decimal separator, use ';' to separate arguments", numberBuffer)
+				}
+				ch = '.'
+			case ch == '.' && numberBuffer != "":
+				if !isDigitGroup(chars[i+1:]) || containsDot(numberBuffer) {
+					return nil, nil, errorAt(numberStart, i+1, "invalid number %q: use ',' as the decimal separator", numberBuffer+".")
+				}
+				// Groups after the first have three digits (checked above);
+				// the leading group has one to three
+				if !grouped && len(numberBuffer) > 3 {
+					return nil, nil, errorAt(numberStart, i+1, "invalid number %q: misplaced grouping mark", numberBuffer+".")
+				}
+				grouped = true
+				continue // grouping mark in 1.234,5
+			case ch == ';':
+				ch = ','
+			}
+		}
 
 		switch {
 		case unicode.IsDigit(ch) || ch == '.':
 			if ch == '.' && containsDot(numberBuffer) {
-				return nil, fmt.Errorf("invalid number: multiple decimal points in %q", numberBuffer+string(ch))
+				return nil, nil, errorAt(numberStart, i+1, "invalid number: multiple decimal points in %q", numberBuffer+string(ch))
 			}
 			if wordBuffer != "" {
 				wordBuffer += string(ch)
 				continue
 			}
+			if numberBuffer == "" {
+				numberStart = i
+				grouped = false
+			}
 			numberBuffer += string(ch)
 
-			if i+1 < len(input) && (input[i+1] == 'e' || input[i+1] == 'E') {
+			if i+1 < len(chars) && (chars[i+1] == 'e' || chars[i+1] == 'E') {
 				i++
-				numberBuffer += string(input[i])
+				numberBuffer += string(chars[i])
 
 				// Handle optional sign
-				if i+1 < len(input) && (input[i+1] == '+' || input[i+1] == '-') {
+				if i+1 < len(chars) && (chars[i+1] == '+' || chars[i+1] == '-') {
 					i++
-					numberBuffer += string(input[i])
+					numberBuffer += string(chars[i])
 				}
 
 				// Require digits after exponent
 				digitsFound := false
-				for i+1 < len(input) && unicode.IsDigit(rune(input[i+1])) {
+				for i+1 < len(chars) && unicode.IsDigit(chars[i+1]) {
 					i++
-					numberBuffer += string(input[i])
+					numberBuffer += string(chars[i])
 					digitsFound = true
 				}
 
 				if !digitsFound {
-					return nil, fmt.Errorf("invalid scientific notation in %q", numberBuffer)
+					return nil, nil, errorAt(numberStart, i+1, "invalid scientific notation in %q", numberBuffer)
 				}
 			}
 
-		// Handle alphabetic characters (functions and identifiers)
-		case unicode.IsLetter(ch):
+		// Handle alphabetic characters (functions and identifiers), plus the
+		// unit marks °, ′ and ″ and superscripts inside words (m², °C)
+		case unicode.IsLetter(ch) || ch == '_' || isUnitMark(ch):
+			if wordBuffer == "" {
+				wordStart = i
+			}
+			wordBuffer += string(ch)
+
+		case wordBuffer != "" && (ch == '²' || ch == '³'):
 			wordBuffer += string(ch)
 
+		// Handle result references: $3 and @h12
+		case ch == '$' || ch == '@':
+			flush(i)
+			end := i + 1
+			if ch == '@' && end < len(chars) && chars[end] == 'h' {
+				end++
+			}
+			digits := end
+			for end < len(chars) && unicode.IsDigit(chars[end]) {
+				end++
+			}
+			if end == digits || (ch == '@' && digits == i+1) {
+				return nil, nil, errorAt(i, end, "invalid reference %q: use $n for a result or @hn for a history entry", string(chars[i:end]))
+			}
+			addToken(Token{Type: IDENT, Value: string(chars[i:end])}, Span{i, end})
+			i = end - 1
+
 		// Handle mathematical operators
 		case ch == '+' || ch == '-' || ch == '*' || ch == '/' || ch == '^':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			addToken(Token{Type: OPERATOR, Value: string(ch)})
+			flush(i)
+			addToken(Token{Type: OPERATOR, Value: string(ch)}, Span{i, i + 1})
 
 		// Handle assignment operator
 		case ch == '=':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			if i+1 < len(input) {
-				next := rune(input[i+1])
+			flush(i)
+			if i+1 < len(chars) {
+				next := chars[i+1]
 				if next == '=' {
-					addToken(Token{Type: COMPARISON, Value: string(ch) + string(next)})
+					addToken(Token{Type: COMPARISON, Value: string(ch) + string(next)}, Span{i, i + 2})
 					i++
 					continue
 				}
 			}
-			addToken(Token{Type: ASSIGN, Value: "="})
+			addToken(Token{Type: ASSIGN, Value: "="}, Span{i, i + 1})
 
 		case ch == '>' || ch == '<':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			if i+1 < len(input) {
-				next := rune(input[i+1])
+			flush(i)
+			if i+1 < len(chars) {
+				next := chars[i+1]
 				if next == '=' {
-					addToken(Token{Type: COMPARISON, Value: string(ch) + string(next)})
+					addToken(Token{Type: COMPARISON, Value: string(ch) + string(next)}, Span{i, i + 2})
 					i++
 					continue
 				}
 			}
-			addToken(Token{Type: COMPARISON, Value: string(ch)})
+			addToken(Token{Type: COMPARISON, Value: string(ch)}, Span{i, i + 1})
 
 		case ch == '&' || ch == '|':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			if i+1 < len(input) {
-				next := rune(input[i+1])
+			flush(i)
+			if i+1 < len(chars) {
+				next := chars[i+1]
 				if next == ch {
-					addToken(Token{Type: LOGICAL, Value: string(ch) + string(next)})
+					addToken(Token{Type: LOGICAL, Value: string(ch) + string(next)}, Span{i, i + 2})
 					i++
 					continue
 				}
 			}
-			return nil, fmt.Errorf("invalid logical operator: %q", ch)
+			return nil, nil, errorAt(i, i+1, "invalid logical operator: %q", ch)
 
 		case ch == '(' || ch == ')':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			addToken(Token{Type: PAREN, Value: string(ch)})
+			flush(i)
+			addToken(Token{Type: PAREN, Value: string(ch)}, Span{i, i + 1})
 
 		// Handle factorial
 		case ch == '!':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			if i+1 < len(input) {
-				next := rune(input[i+1])
+			flush(i)
+			if i+1 < len(chars) {
+				next := chars[i+1]
 				if next == '=' {
-					addToken(Token{Type: COMPARISON, Value: string(ch) + string(next)})
+					addToken(Token{Type: COMPARISON, Value: string(ch) + string(next)}, Span{i, i + 2})
 					i++
 					continue
 				}
 			}
 
-			addToken(Token{Type: FUNCTION, Value: "!"})
+			addToken(Token{Type: FUNCTION, Value: "!"}, Span{i, i + 1})
 
-		// Handle whitespace
+		// Handle whitespace, which may join the words of a unit phrase
 		case unicode.IsSpace(ch):
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
+			if second, end := phraseRest(wordBuffer, chars[i:]); end > 0 {
+				wordBuffer += " " + second
+				i += end - 1
+				continue
+			}
+			flush(i)
 
 		// Handle comma (function argument separator)
 		case ch == ',':
-			flushBuffers(&numberBuffer, &wordBuffer, addToken)
-			addToken(Token{Type: OPERATOR, Value: ","})
+			flush(i)
+			addToken(Token{Type: OPERATOR, Value: ","}, Span{i, i + 1})
 
 		// Handle invalid characters
 		default:
-			return nil, fmt.Errorf("invalid character: %q", ch)
+			return nil, nil, errorAt(i, i+1, "invalid character: %q", ch)
 		}
 	}
 
 	// Process any remaining buffered content
-	flushBuffers(&numberBuffer, &wordBuffer, addToken)
+	flush(len(chars))
+
+	return tokens, spans, nil
+}
 
-	return tokens, nil
+// UnitPhrases lists the unit names made of several words, such as "fl oz";
+// the words may be separated by any amount of whitespace. Loading custom
+// units adds their multi-word names ("rack units")
+var UnitPhrases = []string{"fl oz"}
+
+// phraseRest completes a unit phrase whose first word is word: rest starts
+// at the whitespace after word, and the remaining words and the number of
+// runes of rest they take up are returned, or 0 if no phrase continues there.
+// The longest phrase wins
+func phraseRest(word string, rest []rune) (string, int) {
+	if word == "" {
+		return "", 0
+	}
+	var best string
+	bestEnd := 0
+	for _, phrase := range UnitPhrases {
+		words := strings.Fields(phrase)
+		if len(words) < 2 || words[0] != word {
+			continue
+		}
+		end := matchWords(words[1:], rest)
+		if end > bestEnd {
+			best, bestEnd = strings.Join(words[1:], " "), end
+		}
+	}
+	return best, bestEnd
+}
+
+// matchWords returns the number of runes of rest taken up by words, each
+// preceded by whitespace, or 0 if rest does not continue with them
+func matchWords(words []string, rest []rune) int {
+	end := 0
+	for _, w := range words {
+		start := end
+		for start < len(rest) && unicode.IsSpace(rest[start]) {
+			start++
+		}
+		if start == end {
+			return 0
+		}
+		end = start + len([]rune(w))
+		if end > len(rest) || string(rest[start:end]) != w {
+			return 0
+		}
+	}
+	if end < len(rest) && (unicode.IsLetter(rest[end]) || unicode.IsDigit(rest[end]) || rest[end] == '_') {
+		return 0 // a longer word, e.g. "fl ozone"
+	}
+	return end
+}
+
+// isUnitMark reports whether ch is a non-letter symbol used in unit names
+func isUnitMark(ch rune) bool {
+	return ch == '°' || ch == '′' || ch == '″'
+}
+
+// isDigitGroup reports whether rest starts with exactly three digits, as
+// after a grouping mark
+func isDigitGroup(rest []rune) bool {
+	if len(rest) < 3 {
+		return false
+	}
+	for _, ch := range rest[:3] {
+		if !unicode.IsDigit(ch) {
+			return false
+		}
+	}
+	return len(rest) == 3 || !unicode.IsDigit(rest[3])
+}
+
+// NormalizeNumber rewrites a number written with the input separators to
+// the plain form accepted by strconv (1.234,5 → 1234.5 with DecimalComma)
+func NormalizeNumber(s string) string {
+	if !DecimalComma {
+		return s
+	}
+	return strings.NewReplacer(".", "", ",", ".").Replace(s)
 }
 
 // containsDot checks for decimal point in number buffer
@@ -227,35 +422,51 @@ func containsDot(s string) bool {
 	return false
 }
 
-// isMathFunction checks if a word is a mathematical function
-func isMathFunction(word string) bool {
-	functions := map[string]bool{
-		"sin": true, "cos": true, "tan": true,
-		"asin": true, "acos": true, "atan": true, "atan2": true,
+// functions is the registry of built-in function names
+var functions = map[string]bool{
+	"sin": true, "cos": true, "tan": true,
+	"asin": true, "acos": true, "atan": true, "atan2": true,
 
-		// Logarithmic
-		"log": true, "log10": true, "log2": true, "ln": true,
+	// Logarithmic
+	"log": true, "log10": true, "log2": true, "ln": true,
 
-		// Power/Root
-		"sqrt": true, "exp": true, "pow": true,
+	// Power/Root
+	"sqrt": true, "exp": true, "pow": true,
 
-		// Rounding
-		"abs": true, "ceil": true, "floor": true, "round": true, "trunc": true,
+	// Rounding
+	"abs": true, "ceil": true, "floor": true, "round": true, "trunc": true,
 
-		// Utility
-		"sign": true, "mod": true,
+	// Utility
+	"sign": true, "mod": true,
 
-		// Conversion
-		"deg2rad": true, "rad2deg": true,
+	// Conversion
+	"deg2rad": true, "rad2deg": true,
 
-		// Statistical
-		"max": true, "min": true, "mean": true, "median": true,
-		"mode": true, "sum": true, "product": true,
+	// Statistical
+	"max": true, "min": true, "mean": true, "median": true,
+	"mode": true, "sum": true, "product": true,
 
-		//reserved
-		"print": true,
-		"derivative": true,
-		"fib": true,
-	}
+	// Random
+	"rand": true, "randint": true, "randn": true, "choice": true,
+	"simulate": true,
+
+	//reserved
+	"print": true,
+	"derivative": true,
+	"fib": true,
+}
+
+// isMathFunction checks if a word is a mathematical function
+func isMathFunction(word string) bool {
 	return functions[word]
 }
+
+// Functions returns the names of the built-in functions in sorted order
+func Functions() []string {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -38,6 +38,80 @@ func TestTokenize_Numbers(t *testing.T) {
 	}
 }
 
+func TestTokenize_DecimalComma(t *testing.T) {
+	DecimalComma = true
+	t.Cleanup(func() { DecimalComma = false })
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "decimal comma",
+			input: "3,14",
+			want:  []Token{{Type: NUMBER, Value: "3.14"}},
+		},
+		{
+			name:  "grouped thousands",
+			input: "1.234.567,89",
+			want:  []Token{{Type: NUMBER, Value: "1234567.89"}},
+		},
+		{
+			name:  "semicolon separates arguments",
+			input: "max(1,5; 2)",
+			want: []Token{
+				{Type: FUNCTION, Value: "max"},
+				{Type: PAREN, Value: "("},
+				{Type: NUMBER, Value: "1.5"},
+				{Type: OPERATOR, Value: ","},
+				{Type: NUMBER, Value: "2"},
+				{Type: PAREN, Value: ")"},
+			},
+		},
+		{
+			name:  "namespaced names keep their dot",
+			input: "2 phys.c",
+			want: []Token{
+				{Type: NUMBER, Value: "2"},
+				{Type: OPERATOR, Value: "*", Implicit: true},
+				{Type: IDENT, Value: "phys.c"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tokenize(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, input := range []string{"1.5", "1,234.5", "12.34", "1234567.891", "12.3456", "1.234567,8"} {
+		_, err := Tokenize(input)
+		assert.Error(t, err, "input %q", input)
+	}
+
+	// A comma that is not followed by a digit is an English argument separator
+	for _, tt := range []struct {
+		input string
+		span  Span
+	}{
+		{"sum(1, 2)", Span{5, 6}},
+		{"max(1, 2)", Span{5, 6}},
+		{"2,", Span{1, 2}},
+	} {
+		_, _, err := Scan(tt.input)
+		var tokErr *Error
+		if assert.ErrorAs(t, err, &tokErr, "input %q", tt.input) {
+			assert.Equal(t, tt.span, tokErr.Span)
+			assert.Contains(t, tokErr.Msg, "use ';' to separate arguments")
+		}
+	}
+	assert.Equal(t, "1234.5", NormalizeNumber("1.234,5"))
+}
+
 func TestTokenize_Functions(t *testing.T) {
 	tests := []struct {
 		name, input string
@@ -77,6 +151,58 @@ func TestTokenize_Ident(t *testing.T) {
 		{
 			"Ident4", "root", []Token{{Type: IDENT, Value: "root"}},
 		},
+		{
+			"Unicode unit", "µm", []Token{{Type: IDENT, Value: "µm"}},
+		},
+		{
+			"Degree sign", "°C", []Token{{Type: IDENT, Value: "°C"}},
+		},
+		{
+			"Superscript", "m²", []Token{{Type: IDENT, Value: "m²"}},
+		},
+		{
+			"Underscore", "x_1 * _", []Token{
+				{Type: IDENT, Value: "x_1"},
+				{Type: OPERATOR, Value: "*"},
+				{Type: IDENT, Value: "_"},
+			},
+		},
+		{
+			"Result references", "$3+@h12", []Token{
+				{Type: IDENT, Value: "$3"},
+				{Type: OPERATOR, Value: "+"},
+				{Type: IDENT, Value: "@h12"},
+			},
+		},
+		{
+			"Reference after number", "2$1", []Token{
+				{Type: NUMBER, Value: "2"},
+				{Type: OPERATOR, Value: "*", Implicit: true},
+				{Type: IDENT, Value: "$1"},
+			},
+		},
+		{
+			"Degrees after number", "90°", []Token{
+				{Type: NUMBER, Value: "90"},
+				{Type: OPERATOR, Value: "*", Implicit: true},
+				{Type: IDENT, Value: "°"},
+			},
+		},
+		{
+			"Two-word unit", "2 fl  oz+1", []Token{
+				{Type: NUMBER, Value: "2"},
+				{Type: OPERATOR, Value: "*", Implicit: true},
+				{Type: IDENT, Value: "fl oz"},
+				{Type: OPERATOR, Value: "+"},
+				{Type: NUMBER, Value: "1"},
+			},
+		},
+		{
+			"Not a two-word unit", "fl ozone", []Token{
+				{Type: IDENT, Value: "fl"},
+				{Type: IDENT, Value: "ozone"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -200,7 +326,7 @@ func TestTokenize_ImplicitMultiplication(t *testing.T) {
 			input: "2sin(x)",
 			want: []Token{
 				{Type: NUMBER, Value: "2"},
-				{Type: OPERATOR, Value: "*"}, // IMPLICIT
+				{Type: OPERATOR, Value: "*", Implicit: true}, // IMPLICIT
 				{Type: FUNCTION, Value: "sin"},
 				{Type: PAREN, Value: "("},
 				{Type: IDENT, Value: "x"},
@@ -212,7 +338,7 @@ func TestTokenize_ImplicitMultiplication(t *testing.T) {
 			input: "2(3+4)",
 			want: []Token{
 				{Type: NUMBER, Value: "2"},
-				{Type: OPERATOR, Value: "*"}, // IMPLICIT
+				{Type: OPERATOR, Value: "*", Implicit: true}, // IMPLICIT
 				{Type: PAREN, Value: "("},
 				{Type: NUMBER, Value: "3"},
 				{Type: OPERATOR, Value: "+"},
@@ -364,3 +490,38 @@ func BenchmarkTokenize_Scientific(b *testing.B) {
 		Tokenize(input)
 	}
 }
+
+func TestScan_Spans(t *testing.T) {
+	tokens, spans, err := Scan("2x + sin(π)")
+
+	assert.NoError(t, err)
+	assert.Len(t, spans, len(tokens))
+	assert.Equal(t, []Span{
+		{0, 1}, {1, 1}, {1, 2}, // 2, implicit *, x
+		{3, 4}, // +
+		{5, 8}, {8, 9}, {9, 10}, {10, 11},
+	}, spans)
+}
+
+func TestScan_PhraseSpan(t *testing.T) {
+	_, spans, err := Scan("1 fl oz")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Span{{0, 1}, {2, 2}, {2, 7}}, spans)
+}
+
+func TestScan_ErrorSpan(t *testing.T) {
+	_, _, err := Scan("1 + 3.14.15")
+
+	var tokErr *Error
+	assert.ErrorAs(t, err, &tokErr)
+	assert.Equal(t, Span{4, 9}, tokErr.Span)
+
+	_, _, err = Scan("2 $ 3")
+	assert.ErrorAs(t, err, &tokErr)
+	assert.Equal(t, Span{2, 3}, tokErr.Span)
+
+	_, _, err = Scan("1 + @x")
+	assert.ErrorAs(t, err, &tokErr)
+	assert.Equal(t, Span{4, 5}, tokErr.Span)
+}
@@ -0,0 +1,273 @@
+/*
+Auto Units - Best-Unit Selection and Mixed-Unit Breakdown
+=========================================================
+Part of Axion CLI Calculator
+
+This file turns a converted value into the unit a person would pick:
+
+    Breakdown(1.75, "m", []string{"ft", "in"})  →  5 ft 8.89764 in
+    Auto(93784, "s")                            →  1 d 2 h 3 min 4 s
+    Auto(1500, "m")                             →  1.5 km
+    Auto(30, "in")                              →  2.5 ft
+
+Auto stays within the family of the source unit. Metric units move along
+their SI prefixes in steps of 1000 (binary-prefixed data in steps of 1024)
+so the mantissa lands between 1 and 1000. Imperial units move along a fixed
+ladder (in → ft → yd → mi) to the largest unit still giving at least 1.
+Durations of a minute or more are broken down into days, hours, minutes and
+seconds. Anything else is returned unchanged.
+
+PreferredSystem moves values across systems first: with "imperial", metric
+lengths, masses and volumes are expressed on the imperial ladders; with
+"metric", imperial units are expressed in prefixed SI units.
+*/
+
+package units
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Part is one component of a converted value, e.g. the "5 ft" of "5 ft 8.9 in"
+type Part struct {
+	Value float64 // Magnitude in Unit
+	Unit  string  // Unit symbol
+}
+
+// imperialLadders lists non-metric units Auto may move between, smallest first
+var imperialLadders = [][]string{
+	{"in", "ft", "yd", "mi"},
+	{"oz", "lb"},
+	{"floz", "cup", "pt", "qt", "gal"},
+}
+
+// PreferredSystem steers Auto towards "metric" or "imperial" units;
+// empty keeps the system of the source unit
+var PreferredSystem string
+
+// durationBreakdown lists the units used for durations of a minute or more
+var durationBreakdown = []string{"d", "h", "min", "s"}
+
+// Breakdown expresses value (in unit from) as a sum of the target units, all
+// whole numbers except the smallest, which takes the remainder. Targets are
+// used largest first regardless of the order given. The sign, if any, is
+// carried by the first part.
+func Breakdown(value float64, from string, targets []string) ([]Part, error) {
+	source, err := Parse(from)
+	if err != nil {
+		return nil, err
+	}
+	if source.Offset != 0 {
+		return nil, fmt.Errorf("cannot break down %s, which is an offset scale", from)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no target units given")
+	}
+
+	resolved := make([]Unit, len(targets))
+	for i, t := range targets {
+		u, err := Parse(t)
+		if err != nil {
+			return nil, err
+		}
+		if u.Dim != source.Dim {
+			return nil, Errorf("dimension mismatch: %s is %s but %s is %s", from, describeDim(source), t, describeDim(u))
+		}
+		if u.Offset != 0 {
+			return nil, fmt.Errorf("cannot break down into %s, which is an offset scale", t)
+		}
+		resolved[i] = u
+	}
+	sort.SliceStable(resolved, func(i, j int) bool { return resolved[i].Factor > resolved[j].Factor })
+
+	remaining := math.Abs(value * source.Factor)
+	parts := make([]Part, len(resolved))
+	for i, u := range resolved {
+		if i == len(resolved)-1 {
+			parts[i] = Part{Value: remaining / u.Factor, Unit: u.Symbol}
+			break
+		}
+		// Tolerate rounding error so 0.9999999 of a unit still counts as one
+		whole := math.Floor(remaining/u.Factor + 1e-9)
+		parts[i] = Part{Value: whole, Unit: u.Symbol}
+		remaining = math.Max(remaining-whole*u.Factor, 0)
+	}
+
+	if value < 0 {
+		for i := range parts {
+			if parts[i].Value != 0 || i == len(parts)-1 {
+				parts[i].Value = -parts[i].Value
+				break
+			}
+		}
+	}
+	return parts, nil
+}
+
+// Auto expresses value (in unit from) in the most readable unit of the same
+// family; durations may come back as several parts
+func Auto(value float64, from string) ([]Part, error) {
+	source, err := Parse(from)
+	if err != nil {
+		return nil, err
+	}
+	unchanged := []Part{{Value: value, Unit: from}}
+	if value == 0 || math.IsInf(value, 0) || math.IsNaN(value) || source.Offset != 0 {
+		return unchanged, nil
+	}
+	base := math.Abs(value * source.Factor)
+
+	if target, ok := systemUnit(source); ok {
+		converted, err := Convert(value, from, target)
+		if err != nil {
+			return nil, err
+		}
+		return Auto(converted, target)
+	}
+
+	if source.Dim == (Dimension{Time: 1}) && base >= 60 {
+		parts, err := Breakdown(value, from, durationBreakdown)
+		if err != nil {
+			return nil, err
+		}
+		return dropZeroParts(parts), nil
+	}
+
+	for _, ladder := range imperialLadders {
+		if containsFactor(ladder, source) {
+			best := ladder[0]
+			for _, symbol := range ladder {
+				if u, _ := Lookup(symbol); base/u.Factor >= 1-1e-9 {
+					best = symbol
+				}
+			}
+			converted, err := Convert(value, from, best)
+			return []Part{{Value: converted, Unit: best}}, err
+		}
+	}
+
+	if family, binary, ok := prefixFamily(source); ok {
+		step := 1000.0
+		if binary {
+			step = 1024
+		}
+		candidates := []Prefix{{Factor: 1}}
+		for _, p := range prefixes {
+			wanted := p.Family == PrefixBinary
+			if wanted == binary && family.Prefixes.accepts(p) && isPowerOf(p.Factor, step) && p.Name != "" {
+				candidates = append(candidates, p)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Factor < candidates[j].Factor })
+
+		best := candidates[0]
+		for _, p := range candidates {
+			if base/(p.Factor*family.Factor) >= 1 {
+				best = p
+			}
+		}
+		symbol := best.Symbol + family.Symbol
+		if u, ok := index[symbol]; ok {
+			symbol = u.Symbol // kg rather than a prefixed gram
+		}
+		converted, err := Convert(value, from, symbol)
+		return []Part{{Value: converted, Unit: symbol}}, err
+	}
+
+	return unchanged, nil
+}
+
+// systemUnit returns the unit of PreferredSystem that source should be
+// converted to before choosing the best unit, if it belongs to the other system
+func systemUnit(source Unit) (string, bool) {
+	switch PreferredSystem {
+	case "imperial":
+		if _, _, metric := prefixFamily(source); !metric {
+			return "", false
+		}
+		for _, ladder := range imperialLadders {
+			if u, ok := index[ladder[0]]; ok && u.Dim == source.Dim {
+				return ladder[0], true
+			}
+		}
+	case "metric":
+		for _, ladder := range imperialLadders {
+			if !containsFactor(ladder, source) {
+				continue
+			}
+			for _, c := range registry {
+				if c.Dim != source.Dim {
+					continue
+				}
+				for _, u := range c.Units {
+					if u.Prefixes != 0 && !u.Absolute {
+						return u.Symbol, true
+					}
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// prefixFamily finds the prefixable unit that source is a (possibly
+// prefixed) form of, reporting whether the prefix is a binary one
+func prefixFamily(source Unit) (Unit, bool, bool) {
+	for _, c := range registry {
+		if c.Dim != source.Dim {
+			continue
+		}
+		for _, u := range c.Units {
+			if u.Prefixes == 0 || u.Absolute != source.Absolute {
+				continue
+			}
+			ratio := source.Factor / u.Factor
+			if nearly(ratio, 1) {
+				return u, false, true
+			}
+			for _, p := range prefixes {
+				if nearly(ratio, p.Factor) && u.Prefixes.accepts(p) {
+					return u, p.Family == PrefixBinary, true
+				}
+			}
+		}
+	}
+	return Unit{}, false, false
+}
+
+// containsFactor reports whether source is one of the ladder's units
+func containsFactor(ladder []string, source Unit) bool {
+	for _, symbol := range ladder {
+		if u, ok := index[symbol]; ok && u.Dim == source.Dim && nearly(u.Factor, source.Factor) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropZeroParts removes zero components from a breakdown, keeping at least one
+func dropZeroParts(parts []Part) []Part {
+	var kept []Part
+	for _, p := range parts {
+		if p.Value != 0 {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		return parts[len(parts)-1:]
+	}
+	return kept
+}
+
+// isPowerOf reports whether f is an integer power of step
+func isPowerOf(f, step float64) bool {
+	exp := math.Log(f) / math.Log(step)
+	return math.Abs(exp-math.Round(exp)) < 1e-9
+}
+
+// nearly compares two factors with a relative tolerance
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,239 @@
+/*
+Currency Module - Offline Exchange Rates
+========================================
+Part of Axion CLI Calculator
+
+This file adds a Currency category backed by a locally maintained, dated
+rate table, so currency conversion works without network access. A table
+lists how many units of each currency one unit of the base currency buys:
+
+    JSON: {"base": "USD", "date": "2026-10-01", "rates": {"EUR": 0.92, "GBP": 0.79}}
+
+    CSV:  date,base,currency,rate
+          2026-10-01,USD,EUR,0.92
+          2026-10-01,USD,GBP,0.79
+
+Every currency becomes a unit whose factor is its value in the base
+currency, so cross rates (EUR → GBP) follow from the ordinary conversion
+formula. Loading a new table replaces the previous one. Callers can check
+the table's date with Stale to warn about outdated rates.
+*/
+
+package units
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currencyCategory is the name of the category holding currency units
+const currencyCategory = "Currency"
+
+// rateDateLayout is the date format used in rate tables
+const rateDateLayout = "2006-01-02"
+
+// Rates is a dated table of exchange rates relative to a base currency
+type Rates struct {
+	Base  string             `json:"base"`  // Base currency code
+	Date  string             `json:"date"`  // Date the rates were published (YYYY-MM-DD)
+	Rates map[string]float64 `json:"rates"` // Units of each currency per unit of base
+}
+
+// currentRates holds the active rate table, if any
+var currentRates *Rates
+
+// CurrentRates returns the active rate table, or nil if none is loaded
+func CurrentRates() *Rates {
+	return currentRates
+}
+
+// ReadRates parses a JSON or CSV rate table, chosen by file extension
+func ReadRates(path string) (Rates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to read rates file: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseRatesCSV(data)
+	}
+	return parseRatesJSON(data)
+}
+
+// parseRatesJSON parses a rate table in JSON format
+func parseRatesJSON(data []byte) (Rates, error) {
+	var r Rates
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Rates{}, fmt.Errorf("failed to parse rates: %w", err)
+	}
+	return r, r.validate()
+}
+
+// parseRatesCSV parses a rate table with date,base,currency,rate columns
+func parseRatesCSV(data []byte) (Rates, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	r := Rates{Rates: make(map[string]float64)}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Rates{}, fmt.Errorf("failed to parse rates: %w", err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue // header
+		}
+
+		date, base, code := record[0], strings.ToUpper(record[1]), strings.ToUpper(record[2])
+		if r.Date == "" {
+			r.Date, r.Base = date, base
+		} else if date != r.Date || base != r.Base {
+			return Rates{}, fmt.Errorf("line %d: all rates must share one date and base currency", line)
+		}
+		rate, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return Rates{}, fmt.Errorf("line %d: invalid rate %q", line, record[3])
+		}
+		r.Rates[code] = rate
+	}
+	return r, r.validate()
+}
+
+// validate checks the rate table's base, date, codes and rates
+func (r *Rates) validate() error {
+	r.Base = strings.ToUpper(r.Base)
+	if !isCurrencyCode(r.Base) {
+		return fmt.Errorf("invalid base currency %q", r.Base)
+	}
+	if _, err := time.Parse(rateDateLayout, r.Date); err != nil {
+		return fmt.Errorf("invalid rates date %q (expected YYYY-MM-DD)", r.Date)
+	}
+	if len(r.Rates) == 0 {
+		return fmt.Errorf("rates file contains no rates")
+	}
+
+	normalised := make(map[string]float64, len(r.Rates))
+	for code, rate := range r.Rates {
+		code = strings.ToUpper(code)
+		if !isCurrencyCode(code) {
+			return fmt.Errorf("invalid currency code %q", code)
+		}
+		if !(rate > 0) {
+			return fmt.Errorf("invalid rate for %s: %g", code, rate)
+		}
+		normalised[code] = rate
+	}
+	if rate, ok := normalised[r.Base]; ok && rate != 1 {
+		return fmt.Errorf("rate of base currency %s must be 1, got %g", r.Base, rate)
+	}
+	normalised[r.Base] = 1
+	r.Rates = normalised
+	return nil
+}
+
+// Time returns the date of the rate table
+func (r Rates) Time() time.Time {
+	t, _ := time.Parse(rateDateLayout, r.Date)
+	return t
+}
+
+// Stale reports whether the rates are older than maxAge at time now
+func (r Rates) Stale(now time.Time, maxAge time.Duration) bool {
+	return now.Sub(r.Time()) > maxAge
+}
+
+// Codes returns the currency codes of the table in alphabetical order
+func (r Rates) Codes() []string {
+	codes := make([]string, 0, len(r.Rates))
+	for code := range r.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// SetRates installs r as the active rate table, replacing the Currency
+// category. Currency codes that clash with existing units are rejected.
+func SetRates(r Rates) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	dim := Dimension{Currency: 1}
+	for code := range r.Rates {
+		if existing, ok := index[code]; ok && existing.Category != currencyCategory {
+			return fmt.Errorf("currency %s clashes with unit %s", code, existing.Symbol)
+		}
+	}
+
+	// Replace the previous table, if any
+	for i, c := range registry {
+		if c.Name == currencyCategory {
+			for _, u := range c.Units {
+				for _, key := range u.keys() {
+					delete(index, key)
+				}
+			}
+			registry = append(registry[:i], registry[i+1:]...)
+			break
+		}
+	}
+
+	category := Category{Name: currencyCategory, Dim: dim}
+	for _, code := range r.Codes() {
+		u := Unit{Symbol: code, Factor: 1 / r.Rates[code], Dim: dim, Category: currencyCategory}
+		if code == r.Base {
+			category.Units = append([]Unit{u}, category.Units...)
+		} else {
+			category.Units = append(category.Units, u)
+		}
+	}
+
+	registry = append(registry, category)
+	for _, u := range category.Units {
+		index[u.Symbol] = u
+	}
+	for i, b := range baseSymbols {
+		if b.index == Currency {
+			baseSymbols[i].symbol = r.Base
+		}
+	}
+	currentRates = &r
+	return nil
+}
+
+// SaveRates writes r as JSON to path, creating parent directories
+func SaveRates(path string, r Rates) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// isCurrencyCode reports whether s is a three-letter ISO 4217 style code
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, ch := range s {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,472 @@
+/*
+Custom Units - User-Defined Units and Categories
+================================================
+Part of Axion CLI Calculator
+
+This file loads additional units from a JSON file so teams can extend the
+built-in registry without recompiling. A units file may add single units to
+existing categories or define whole new categories:
+
+    {
+      "units": [
+        {"symbol": "U", "name": "rack unit", "definition": "1.75 in", "aliases": ["RU"]},
+        {"symbol": "sprint", "definition": "2 wk"},
+        {"symbol": "mpg", "definition": "mi/gal", "category": "Fuel Economy"}
+      ],
+      "categories": [
+        {"name": "Effort", "units": [
+          {"symbol": "sp", "name": "story point", "aliases": ["pt_story"]},
+          {"symbol": "epic", "definition": "20 sp"}
+        ]}
+      ]
+    }
+
+Units are known by their symbol, aliases, name and the plural of the name
+("3 rack units"); names of several words are registered with the tokenizer
+(UnitPhrases) so that expressions such as 3 rack units in cm read them.
+Definitions are unit expressions (see expr.go) and may refer to built-in
+units or to other units in the same file, in any order. The one unit of a
+category without a definition becomes a new base dimension. Standalone units
+join the category named by "category" (created if missing) or the existing
+category matching their dimension.
+
+Loading is all-or-nothing: symbols that clash with existing units, cyclic
+definitions and dimension mismatches are reported and leave the registry
+untouched.
+
+The units files loaded are remembered (Custom) so that a saved workspace
+can load them again (LoadCustom) in a later session.
+
+An optional "offset" gives the zero point of an offset scale in the
+category's base unit, e.g. {"symbol": "Re", "definition": "1.25 K",
+"offset": 273.15} for degrees Réaumur.
+*/
+
+package units
+
+import (
+	"Axion/tokenizer"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// fileUnit is a unit as written in a units file
+type fileUnit struct {
+	Symbol     string   `json:"symbol"`
+	Name       string   `json:"name,omitempty"`
+	Definition string   `json:"definition,omitempty"`
+	Offset     float64  `json:"offset,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Prefixes   string   `json:"prefixes,omitempty"`
+	Category   string   `json:"category,omitempty"`
+}
+
+// fileCategory is a category as written in a units file
+type fileCategory struct {
+	Name  string     `json:"name"`
+	Units []fileUnit `json:"units"`
+}
+
+// unitFile is the top-level structure of a units file
+type unitFile struct {
+	Units      []fileUnit     `json:"units,omitempty"`
+	Categories []fileCategory `json:"categories,omitempty"`
+}
+
+// pendingUnit tracks a unit from a file while its definition is resolved
+type pendingUnit struct {
+	spec     fileUnit
+	category string // Category named by the enclosing block or the unit itself
+	newBase  bool   // First undefined unit of a new category
+	resolved bool
+	unit     Unit
+}
+
+// customDimensions counts the base dimensions claimed by loaded categories
+var customDimensions int
+
+// loaded holds each units file loaded successfully, encoded as JSON
+var loaded [][]byte
+
+// LoadFile adds the units and categories defined in a JSON units file
+// Returns the number of units added
+func LoadFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read units file: %w", err)
+	}
+	var file unitFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("failed to parse units file: %w", err)
+	}
+	return load(file)
+}
+
+// load validates and registers the contents of a units file
+func load(file unitFile) (int, error) {
+	pending := make(map[string]*pendingUnit)
+	var order []string
+	symbols := make(map[string]string) // symbol or alias → canonical symbol
+
+	claim := func(symbol, owner string) error {
+		if !validSymbol(symbol) {
+			return fmt.Errorf("invalid unit symbol %q", symbol)
+		}
+		if _, exists := Lookup(symbol); exists {
+			return fmt.Errorf("unit %q is already defined", symbol)
+		}
+		if other, exists := symbols[symbol]; exists {
+			return fmt.Errorf("unit %q is defined twice (by %s and %s)", symbol, other, owner)
+		}
+		symbols[symbol] = owner
+		return nil
+	}
+
+	add := func(spec fileUnit, category string, newBase bool) error {
+		if err := claim(spec.Symbol, spec.Symbol); err != nil {
+			return err
+		}
+		for _, alias := range spec.Aliases {
+			if err := claim(alias, spec.Symbol); err != nil {
+				return err
+			}
+		}
+		if spec.Name != "" {
+			for _, name := range []string{spec.Name, plural(spec.Name)} {
+				if _, exists := index[name]; exists || symbols[name] != "" && symbols[name] != spec.Symbol {
+					return fmt.Errorf("unit name %q is already defined", name)
+				}
+				symbols[name] = spec.Symbol
+			}
+		}
+		if spec.Definition == "" && !newBase {
+			return fmt.Errorf("unit %s needs a definition", spec.Symbol)
+		}
+		if _, err := parsePrefixSet(spec.Prefixes); err != nil {
+			return fmt.Errorf("unit %s: %v", spec.Symbol, err)
+		}
+		pending[spec.Symbol] = &pendingUnit{spec: spec, category: category, newBase: newBase}
+		order = append(order, spec.Symbol)
+		return nil
+	}
+
+	newCategories := make(map[string]bool)
+	for _, c := range file.Categories {
+		if c.Name == "" {
+			return 0, fmt.Errorf("category without a name")
+		}
+		if findCategory(c.Name) != nil || newCategories[strings.ToLower(c.Name)] {
+			return 0, fmt.Errorf("category %q is already defined", c.Name)
+		}
+		if len(c.Units) == 0 {
+			return 0, fmt.Errorf("category %s has no units", c.Name)
+		}
+		newCategories[strings.ToLower(c.Name)] = true
+
+		// A unit without a definition becomes the category's new base
+		// dimension and is listed first
+		specs := append([]fileUnit(nil), c.Units...)
+		base := -1
+		for i, spec := range specs {
+			if spec.Definition != "" {
+				continue
+			}
+			if base >= 0 {
+				return 0, fmt.Errorf("category %s has more than one base unit (%s and %s)", c.Name, specs[base].Symbol, spec.Symbol)
+			}
+			base = i
+		}
+		if base > 0 {
+			specs[0], specs[base] = specs[base], specs[0]
+		}
+		for i, spec := range specs {
+			if err := add(spec, c.Name, i == 0 && base >= 0); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for _, spec := range file.Units {
+		if err := add(spec, spec.Category, false); err != nil {
+			return 0, err
+		}
+	}
+
+	// Stage resolved units in the index so later definitions can use them;
+	// everything is rolled back if any definition fails
+	savedSymbols, savedDimensions := append([]baseSymbol(nil), baseSymbols...), customDimensions
+	var staged []string
+	rollback := func() {
+		for _, symbol := range staged {
+			delete(index, symbol)
+		}
+		baseSymbols, customDimensions = savedSymbols, savedDimensions
+	}
+
+	var resolve func(symbol string, path []string) error
+	resolve = func(symbol string, path []string) error {
+		p := pending[symbol]
+		if p.resolved {
+			return nil
+		}
+		for i, seen := range path {
+			if seen == symbol {
+				return fmt.Errorf("cycle in unit definitions: %s", strings.Join(append(path[i:], symbol), " → "))
+			}
+		}
+		path = append(path, symbol)
+
+		for _, ref := range referencedSymbols(p.spec.Definition) {
+			if dep, ok := pendingReference(ref, symbols); ok {
+				if err := resolve(dep, path); err != nil {
+					return err
+				}
+			}
+		}
+
+		u, err := definePending(p)
+		if err != nil {
+			return err
+		}
+		p.unit, p.resolved = u, true
+		for _, s := range u.keys() {
+			index[s] = u
+			staged = append(staged, s)
+		}
+		return nil
+	}
+
+	for _, symbol := range order {
+		if err := resolve(symbol, nil); err != nil {
+			rollback()
+			return 0, err
+		}
+	}
+
+	// Assign every unit to a category and check dimensions agree
+	type newCategory struct {
+		name string
+		dim  Dimension
+	}
+	var created []newCategory
+	for _, symbol := range order {
+		p := pending[symbol]
+		name := p.category
+		if name == "" {
+			name = categoryOf(p.unit.Dim)
+			for _, c := range created {
+				if name == "" && c.dim == p.unit.Dim {
+					name = c.name
+				}
+			}
+			if name == "" {
+				rollback()
+				return 0, fmt.Errorf("unit %s (%s) matches no category; give it a \"category\"", symbol, p.unit.Dim)
+			}
+		}
+
+		dim, known := p.unit.Dim, false
+		if existing := findCategory(name); existing != nil {
+			name, dim, known = existing.Name, existing.Dim, true
+		}
+		for _, c := range created {
+			if strings.EqualFold(c.name, name) {
+				name, dim, known = c.name, c.dim, true
+			}
+		}
+		if dim != p.unit.Dim {
+			rollback()
+			return 0, fmt.Errorf("unit %s (%s) does not match category %s (%s)", symbol, p.unit.Dim, name, dim)
+		}
+		if !known {
+			created = append(created, newCategory{name, dim})
+		}
+		p.unit.Category = name
+	}
+
+	// Commit: append units to their categories and finalise the index
+	for _, symbol := range order {
+		u := pending[symbol].unit
+		if c := findCategory(u.Category); c != nil {
+			c.Units = append(c.Units, u)
+		} else {
+			registry = append(registry, Category{Name: u.Category, Dim: u.Dim, Units: []Unit{u}})
+		}
+		for _, s := range u.keys() {
+			index[s] = u
+			addPhrase(s)
+		}
+	}
+	if data, err := json.Marshal(file); err == nil {
+		loaded = append(loaded, data)
+	}
+	return len(order), nil
+}
+
+// addPhrase lets expressions use a unit name made of several words, such
+// as the plural "rack units", by registering it with the tokenizer
+func addPhrase(name string) {
+	if strings.Contains(name, " ") && !slices.Contains(tokenizer.UnitPhrases, name) {
+		tokenizer.UnitPhrases = append(tokenizer.UnitPhrases, name)
+	}
+}
+
+// Custom returns the units files loaded so far, in order, so that a
+// workspace can restore them
+func Custom() []json.RawMessage {
+	files := make([]json.RawMessage, len(loaded))
+	for i, data := range loaded {
+		files[i] = append(json.RawMessage(nil), data...)
+	}
+	return files
+}
+
+// LoadCustom loads units files returned by Custom, skipping those that are
+// already loaded; it returns the number of units added
+func LoadCustom(files []json.RawMessage) (int, error) {
+	added := 0
+	for _, data := range files {
+		var file unitFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return added, fmt.Errorf("failed to parse units: %w", err)
+		}
+		if isLoaded(file) {
+			continue
+		}
+		n, err := load(file)
+		if err != nil {
+			return added, err
+		}
+		added += n
+	}
+	return added, nil
+}
+
+// isLoaded reports whether the same units file has been loaded before
+func isLoaded(file unitFile) bool {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return false
+	}
+	for _, other := range loaded {
+		if bytes.Equal(data, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// definePending computes the factor and dimension of a pending unit
+func definePending(p *pendingUnit) (Unit, error) {
+	spec := p.spec
+	prefixes, _ := parsePrefixSet(spec.Prefixes)
+	u := Unit{
+		Symbol:   spec.Symbol,
+		Factor:   1,
+		Prefixes: prefixes,
+		Name:     spec.Name,
+		Aliases:  spec.Aliases,
+	}
+
+	if p.newBase {
+		if customDimensions == maxCustomDimensions {
+			return Unit{}, fmt.Errorf("too many custom base dimensions (maximum %d)", maxCustomDimensions)
+		}
+		slot := numBuiltinDimensions + customDimensions
+		customDimensions++
+		u.Dim[slot] = 1
+		baseSymbols = append(baseSymbols, baseSymbol{index: slot, symbol: spec.Symbol})
+		return u, nil
+	}
+
+	base, err := Parse(spec.Definition)
+	if err != nil {
+		return Unit{}, fmt.Errorf("unit %s: %v", spec.Symbol, err)
+	}
+	if base.Offset != 0 {
+		return Unit{}, fmt.Errorf("unit %s: cannot be defined in terms of offset scale %s", spec.Symbol, base.Symbol)
+	}
+	u.Factor = base.Factor
+	u.Dim = base.Dim
+	u.Offset = spec.Offset
+	for _, ref := range referencedSymbols(spec.Definition) {
+		if r, ok := Lookup(ref); ok && r.Absolute {
+			u.Absolute = true
+		}
+	}
+	return u, nil
+}
+
+// referencedSymbols lists the unit symbols that appear in a definition
+func referencedSymbols(definition string) []string {
+	var refs []string
+	runes := []rune(definition)
+	for i := 0; i < len(runes); {
+		if !isSymbolStart(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && isSymbolPart(runes[i]) {
+			i++
+		}
+		refs = append(refs, string(runes[start:i]))
+	}
+	return refs
+}
+
+// pendingReference maps a symbol in a definition to a pending unit, allowing
+// for aliases and prefixes (e.g. "ksp" refers to "sp")
+func pendingReference(ref string, symbols map[string]string) (string, bool) {
+	if owner, ok := symbols[ref]; ok {
+		return owner, true
+	}
+	for _, p := range prefixes {
+		if owner, ok := symbols[strings.TrimPrefix(ref, p.Symbol)]; ok && strings.HasPrefix(ref, p.Symbol) {
+			return owner, true
+		}
+	}
+	return "", false
+}
+
+// parsePrefixSet reads the "prefixes" field of a units file entry
+func parsePrefixSet(s string) (PrefixSet, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return 0, nil
+	case "si":
+		return PrefixSI, nil
+	case "large":
+		return PrefixLarge, nil
+	case "binary":
+		return PrefixLarge | PrefixBinary, nil
+	}
+	return 0, fmt.Errorf("unknown prefix set %q (use si, large, binary or none)", s)
+}
+
+// findCategory returns the registered category with the given name
+func findCategory(name string) *Category {
+	for i := range registry {
+		if strings.EqualFold(registry[i].Name, name) {
+			return &registry[i]
+		}
+	}
+	return nil
+}
+
+// validSymbol reports whether s can be written as a unit symbol
+func validSymbol(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 || !isSymbolStart(runes[0]) {
+		return false
+	}
+	for _, r := range runes {
+		if !isSymbolPart(r) {
+			return false
+		}
+	}
+	return true
+}
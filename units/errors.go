@@ -0,0 +1,31 @@
+/*
+Unit Errors
+===========
+Part of Axion CLI Calculator
+
+Errors caused by units (unknown units, dimension mismatches, incompatible
+operands) are returned as *UnitError so callers can tell them apart from
+syntax and arithmetic errors, e.g. to choose an exit code:
+
+    var unitErr *units.UnitError
+    if errors.As(err, &unitErr) { ... }
+*/
+
+package units
+
+import "fmt"
+
+// UnitError reports a problem with the units of a value or expression
+type UnitError struct {
+	msg string
+}
+
+// Error returns the error message
+func (e *UnitError) Error() string {
+	return e.msg
+}
+
+// Errorf formats a UnitError
+func Errorf(format string, args ...any) error {
+	return &UnitError{msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,223 @@
+/*
+Unit Expressions - Compound Unit Parsing
+========================================
+Part of Axion CLI Calculator
+
+This file parses compound unit expressions such as "km/h", "kg*m/s^2" or
+"g/cm^3" into a single Unit whose factor and dimension are reduced to SI
+base units. The grammar is deliberately small:
+
+    expr   := term (('*' | '·' | '/' | <space>) term)*
+    term   := atom ('^' integer)?
+    atom   := symbol | number | '(' expr ')'
+
+Products and quotients associate to the left, so "m/s/s" is m/s^2 and
+"kg/m*s" is (kg/m)*s. Adjacent units separated only by whitespace are
+multiplied ("N m"), and plain numbers scale a unit ("L/(100 km)"). Offset scales such as C and F are only accepted on their
+own, since a product of absolute temperatures has no meaningful zero point.
+*/
+
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// unitParser walks a compound unit expression one rune at a time
+type unitParser struct {
+	input []rune
+	pos   int
+}
+
+// Parse resolves a unit symbol or compound unit expression to a Unit
+// Single registered or prefixed symbols resolve exactly as Lookup does;
+// anything else is parsed with the unit-expression grammar
+func Parse(expr string) (Unit, error) {
+	expr = strings.TrimSpace(expr)
+	if u, ok := Lookup(expr); ok {
+		return u, nil
+	}
+	if expr == "" {
+		return Unit{}, Errorf("empty unit expression")
+	}
+
+	p := &unitParser{input: []rune(expr)}
+	u, err := p.parseExpr()
+	if err != nil {
+		return Unit{}, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return Unit{}, Errorf("unexpected %q in unit expression %q", p.input[p.pos], expr)
+	}
+
+	u.Symbol = expr
+	u.Category = categoryOf(u.Dim)
+	return u, nil
+}
+
+// parseExpr handles products and quotients of terms
+func (p *unitParser) parseExpr() (Unit, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return Unit{}, err
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return left, nil
+		}
+
+		op := p.input[p.pos]
+		switch {
+		case op == '*' || op == '·' || op == '/':
+			p.pos++
+		case op == '(' || isSymbolStart(op) || unicode.IsDigit(op):
+			op = '*' // juxtaposition multiplies
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseTerm()
+		if err != nil {
+			return Unit{}, err
+		}
+		sign := 1
+		if op == '/' {
+			sign = -1
+		}
+		if left, err = combine(left, right, sign); err != nil {
+			return Unit{}, err
+		}
+	}
+}
+
+// parseTerm handles an atom with an optional integer exponent
+func (p *unitParser) parseTerm() (Unit, error) {
+	atom, err := p.parseAtom()
+	if err != nil {
+		return Unit{}, err
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.input) || p.input[p.pos] != '^' {
+		return atom, nil
+	}
+	p.pos++
+	p.skipSpace()
+
+	start := p.pos
+	if p.pos < len(p.input) && (p.input[p.pos] == '-' || p.input[p.pos] == '+') {
+		p.pos++
+	}
+	for p.pos < len(p.input) && unicode.IsDigit(p.input[p.pos]) {
+		p.pos++
+	}
+	exp, err := strconv.Atoi(string(p.input[start:p.pos]))
+	if err != nil {
+		return Unit{}, Errorf("unit exponent must be an integer")
+	}
+	if atom.Offset != 0 && exp != 1 {
+		return Unit{}, Errorf("%s is an offset scale and cannot be raised to a power", atom.Symbol)
+	}
+
+	result := Unit{Factor: math.Pow(atom.Factor, float64(exp))}
+	for i, d := range atom.Dim {
+		result.Dim[i] = d * exp
+	}
+	return result, nil
+}
+
+// parseAtom handles a unit symbol, a number or a parenthesised expression
+func (p *unitParser) parseAtom() (Unit, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return Unit{}, Errorf("unexpected end of unit expression")
+	}
+
+	ch := p.input[p.pos]
+	switch {
+	case ch == '(':
+		p.pos++
+		inner, err := p.parseExpr()
+		if err != nil {
+			return Unit{}, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return Unit{}, Errorf("missing closing parenthesis in unit expression")
+		}
+		p.pos++
+		return inner, nil
+
+	case unicode.IsDigit(ch):
+		start := p.pos
+		for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+		if err != nil || n == 0 {
+			return Unit{}, Errorf("invalid number %q in unit expression", string(p.input[start:p.pos]))
+		}
+		return Unit{Factor: n}, nil
+
+	case isSymbolStart(ch):
+		start := p.pos
+		for p.pos < len(p.input) && isSymbolPart(p.input[p.pos]) {
+			p.pos++
+		}
+		symbol := string(p.input[start:p.pos])
+		u, ok := Lookup(symbol)
+		if !ok {
+			return Unit{}, unknownUnit(symbol)
+		}
+		return u, nil
+	}
+
+	return Unit{}, Errorf("unexpected %q in unit expression", ch)
+}
+
+// skipSpace advances past whitespace
+func (p *unitParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// combine multiplies (sign 1) or divides (sign -1) two units
+func combine(a, b Unit, sign int) (Unit, error) {
+	for _, u := range []Unit{a, b} {
+		if u.Offset != 0 {
+			return Unit{}, Errorf("%s is an offset scale and cannot be combined with other units", u.Symbol)
+		}
+	}
+	result := Unit{Factor: a.Factor * math.Pow(b.Factor, float64(sign))}
+	for i := range result.Dim {
+		result.Dim[i] = a.Dim[i] + sign*b.Dim[i]
+	}
+	return result, nil
+}
+
+// categoryOf names the registered category with the given dimension, if any
+func categoryOf(dim Dimension) string {
+	for _, c := range registry {
+		if c.Dim == dim {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// isSymbolStart reports whether ch can begin a unit symbol (including °, ′, ″)
+func isSymbolStart(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '°' || ch == '′' || ch == '″'
+}
+
+// isSymbolPart reports whether ch can continue a unit symbol (e.g. m2, cm³)
+func isSymbolPart(ch rune) bool {
+	return isSymbolStart(ch) || unicode.IsDigit(ch) || ch == '²' || ch == '³' || ch == '_'
+}
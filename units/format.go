@@ -0,0 +1,237 @@
+/*
+Number Formatting - Display Modes
+=================================
+Part of Axion CLI Calculator
+
+This file writes numbers in the display modes offered by the calculator,
+where precision is the number of decimal places (fixed) or significant
+digits (every other mode):
+
+    mode    precision 4, value 12345.678
+    auto    1.235e+04       shortest of fixed and exponent form (%g)
+    fixed   12345.6780      decimal places
+    sig     12350           significant figures
+    sci     1.235e4         one digit before the point
+    eng     12.35e3         exponent a multiple of three
+    si      12.35 k         engineering with an SI prefix
+
+In si mode FormatWithUnit merges the prefix into a prefixable unit, so
+1500 m is written as 1.5 km and 0.0012 kg as 1.2 g. Values in units that
+take no prefix (ft, m/s) are written with significant figures instead.
+
+Localize then applies a locale's decimal separator and digit grouping:
+1234567.891 becomes 1,234,567.891 (en), 1.234.567,891 (de),
+1 234 567,891 (fr) or 1'234'567.891 (ch). FormatLocale does both, and in
+auto mode writes numbers below 1e15 in full for a grouping locale, so that
+1234567 at precision 6 shows as 1.234.570 (de) rather than 1,23457e+06.
+*/
+
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Format writes value in the given display mode; unknown modes fall back to auto
+func Format(value float64, mode string, precision int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+∞"
+	case math.IsInf(value, -1):
+		return "-∞"
+	}
+
+	digits := precision
+	if digits < 1 {
+		digits = 1
+	}
+	switch mode {
+	case "fixed":
+		return strconv.FormatFloat(value, 'f', precision, 64)
+	case "sig":
+		return formatSignificant(value, digits)
+	case "sci":
+		mantissa, exp := splitExponent(value, digits)
+		return joinExponent(mantissa, exp)
+	case "eng":
+		mantissa, exp := engineering(value, digits)
+		return joinExponent(mantissa, exp)
+	case "si":
+		mantissa, exp := engineering(value, digits)
+		if exp == 0 {
+			return mantissa
+		}
+		if p, ok := siPrefix(exp); ok {
+			return mantissa + " " + p.Symbol
+		}
+		return joinExponent(mantissa, exp)
+	}
+	return fmt.Sprintf("%.*g", precision, value)
+}
+
+// FormatWithUnit writes value in unit, returning the number and the unit to
+// show; in si mode the prefix is merged into the unit when the unit accepts it
+func FormatWithUnit(value float64, unit, mode string, precision int) (string, string) {
+	if mode != "si" || unit == "" || value == 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return Format(value, mode, precision), unit
+	}
+	source, ok := Lookup(unit)
+	if !ok || source.Offset != 0 {
+		return Format(value, "sig", precision), unit
+	}
+	family, binary, ok := prefixFamily(source)
+	if !ok || binary {
+		return Format(value, "sig", precision), unit
+	}
+
+	digits := precision
+	if digits < 1 {
+		digits = 1
+	}
+	inFamily := value * source.Factor / family.Factor
+	mantissa, exp := engineering(inFamily, digits)
+	if exp == 0 {
+		return mantissa, family.Symbol
+	}
+	if p, ok := siPrefix(exp); ok && family.Prefixes.accepts(p) {
+		symbol := p.Symbol + family.Symbol
+		if u, ok := index[symbol]; ok {
+			symbol = u.Symbol // kg rather than a prefixed gram
+		}
+		return mantissa, symbol
+	}
+	return joinExponent(mantissa, exp), family.Symbol
+}
+
+// FormatLocale writes value like Format with the separators of l; a locale
+// with digit grouping gets large auto-mode numbers without an exponent
+func FormatLocale(value float64, mode string, precision int, l Locale) string {
+	text := Format(value, mode, precision)
+	// Only the auto form (%g) writes a signed exponent such as e+06
+	if _, exponent, ok := strings.Cut(text, "e+"); ok && l.Group != "" {
+		if exp, err := strconv.Atoi(exponent); err == nil && exp < 15 {
+			text = formatSignificant(value, max(precision, 1))
+		}
+	}
+	return Localize(text, l)
+}
+
+// Locale describes how numbers are written in a region
+type Locale struct {
+	Decimal string // Decimal separator
+	Group   string // Thousands separator, empty for no grouping
+}
+
+// Locales lists the supported locales by name
+var Locales = map[string]Locale{
+	"plain": {Decimal: "."},
+	"en":    {Decimal: ".", Group: ","},
+	"de":    {Decimal: ",", Group: "."},
+	"fr":    {Decimal: ",", Group: "\u202f"},
+	"ch":    {Decimal: ".", Group: "'"},
+}
+
+// Localize rewrites a formatted number (as returned by Format) with the
+// separators of l; exponents and SI prefix suffixes are left unchanged
+func Localize(number string, l Locale) string {
+	end := len(number)
+	for i, ch := range number {
+		if ch == 'e' || ch == ' ' {
+			end = i
+			break
+		}
+	}
+	mantissa, suffix := number[:end], number[end:]
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(mantissa, ".")
+	if _, err := strconv.Atoi(integer); err != nil {
+		return number // NaN, ∞
+	}
+
+	if l.Group != "" && len(integer) > 3 {
+		var grouped strings.Builder
+		for i, digit := range integer {
+			if i > 0 && (len(integer)-i)%3 == 0 {
+				grouped.WriteString(l.Group)
+			}
+			grouped.WriteRune(digit)
+		}
+		integer = grouped.String()
+	}
+	if hasFraction {
+		integer += l.Decimal + fraction
+	}
+	return sign + integer + suffix
+}
+
+// formatSignificant writes value with the given number of significant digits,
+// switching to exponent form for very large or small magnitudes
+func formatSignificant(value float64, digits int) string {
+	if value == 0 {
+		return strconv.FormatFloat(0, 'f', digits-1, 64)
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'e', digits-1, 64), 64)
+	exp := int(math.Floor(math.Log10(math.Abs(rounded))))
+	if exp < -6 || exp >= 15 {
+		mantissa, exp := splitExponent(value, digits)
+		return joinExponent(mantissa, exp)
+	}
+	decimals := digits - 1 - exp
+	if decimals < 0 {
+		decimals = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', decimals, 64)
+}
+
+// splitExponent rounds value to digits significant digits and returns the
+// mantissa (one digit before the point) and the decimal exponent
+func splitExponent(value float64, digits int) (string, int) {
+	s := strconv.FormatFloat(value, 'e', digits-1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa, exp
+}
+
+// engineering returns value as a mantissa and an exponent that is a multiple
+// of three, keeping digits significant digits
+func engineering(value float64, digits int) (string, int) {
+	if value == 0 {
+		return strconv.FormatFloat(0, 'f', digits-1, 64), 0
+	}
+	mantissa, exp := splitExponent(value, digits)
+	shift := ((exp % 3) + 3) % 3
+	m, _ := strconv.ParseFloat(mantissa, 64)
+	decimals := digits - 1 - shift
+	if decimals < 0 {
+		decimals = 0
+	}
+	return strconv.FormatFloat(m*math.Pow(10, float64(shift)), 'f', decimals, 64), exp - shift
+}
+
+// joinExponent writes a mantissa and exponent as 1.5e3, omitting a zero exponent
+func joinExponent(mantissa string, exp int) string {
+	if exp == 0 {
+		return mantissa
+	}
+	return mantissa + "e" + strconv.Itoa(exp)
+}
+
+// siPrefix returns the SI prefix for a power of ten that is a multiple of three
+func siPrefix(exp int) (Prefix, bool) {
+	factor := math.Pow(10, float64(exp))
+	for _, p := range prefixes {
+		if p.Family != PrefixBinary && p.Name != "" && nearly(p.Factor, factor) {
+			return p, true
+		}
+	}
+	return Prefix{}, false
+}
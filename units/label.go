@@ -0,0 +1,235 @@
+/*
+Unit Labels - Products and Quotients of Display Units
+=====================================================
+Part of Axion CLI Calculator
+
+A quantity remembers the unit it was written in (its label) so that results
+are shown in the user's units. This file combines the labels of a product or
+quotient, cancelling units that appear on both sides:
+
+    c * 2 s        m/s * s       →  m
+    5 km / 2 h     km / h        →  km/h
+    3 N * 2 m      N * m         →  N*m
+    6 m^2 / 2 m    m^2 / m       →  m
+
+Labels are read with the unit-expression grammar of expr.go, restricted to
+symbols, "1", parentheses and integer powers. When a label cannot be read
+or the combined label no longer matches the result's dimension and scale,
+the result drops its label and is shown in SI base units instead.
+*/
+
+package units
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// factor is one unit symbol of a label raised to a power
+type factor struct {
+	symbol string
+	power  int
+}
+
+// productLabel labels the product (sign 1) or quotient (sign -1) of
+// quantities labelled a and b, whose result has dimension dim and scale;
+// it returns "" when no label describes the result
+func productLabel(a, b string, sign int, dim Dimension, scale float64) string {
+	label := ""
+	left, okLeft := labelFactors(a)
+	right, okRight := labelFactors(b)
+	if okLeft && okRight {
+		label = formatFactors(mergeFactors(left, right, sign))
+	} else if sign > 0 {
+		label = a + "*" + group(b)
+	} else {
+		label = a + "/" + group(b)
+	}
+
+	// The label must still describe the result (a label that cancelled to
+	// nothing has no unit to show)
+	if label == "" {
+		return ""
+	}
+	u, err := Parse(label)
+	if err != nil || u.Dim != dim || !nearly(u.Factor, scale) {
+		return ""
+	}
+	return label
+}
+
+// labelFactors splits a label such as "kg*m/s^2" into its symbols and
+// powers in order of first appearance; ok is false if the label uses
+// anything else, such as a numeric factor. A multi-word unit name ("fl oz")
+// is a single symbol
+func labelFactors(label string) ([]factor, bool) {
+	if _, ok := Lookup(label); ok && strings.Contains(label, " ") {
+		return []factor{{symbol: label, power: 1}}, true
+	}
+	r := labelReader{input: []rune(label)}
+	factors, ok := r.product()
+	r.skipSpace()
+	return factors, ok && r.pos == len(r.input)
+}
+
+// mergeFactors multiplies (sign 1) or divides (sign -1) two factor lists,
+// dropping symbols whose powers cancel
+func mergeFactors(a, b []factor, sign int) []factor {
+	merged := append([]factor(nil), a...)
+	for _, f := range b {
+		found := false
+		for i := range merged {
+			if merged[i].symbol == f.symbol {
+				merged[i].power += sign * f.power
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, factor{f.symbol, sign * f.power})
+		}
+	}
+
+	kept := merged[:0]
+	for _, f := range merged {
+		if f.power != 0 {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// formatFactors writes factors as a label: positive powers first, then
+// "/" and the negative powers (kg*m/s^2, 1/s)
+func formatFactors(factors []factor) string {
+	var numerator, denominator []string
+	for _, f := range factors {
+		power := f.power
+		if power < 0 {
+			power = -power
+		}
+		term := f.symbol
+		if power != 1 {
+			term += "^" + strconv.Itoa(power)
+		}
+		if f.power > 0 {
+			numerator = append(numerator, term)
+		} else {
+			denominator = append(denominator, term)
+		}
+	}
+
+	if len(denominator) == 0 {
+		return strings.Join(numerator, "*")
+	}
+	top := strings.Join(numerator, "*")
+	if top == "" {
+		top = "1"
+	}
+	return top + "/" + group(strings.Join(denominator, "*"))
+}
+
+// labelReader walks a label one rune at a time, like unitParser
+type labelReader struct {
+	input []rune
+	pos   int
+}
+
+// product reads powers joined by '*', '/' or whitespace
+func (r *labelReader) product() ([]factor, bool) {
+	factors, ok := r.power()
+	if !ok {
+		return nil, false
+	}
+	for {
+		r.skipSpace()
+		if r.pos >= len(r.input) {
+			return factors, true
+		}
+		sign := 1
+		switch ch := r.input[r.pos]; {
+		case ch == '*' || ch == '·':
+			r.pos++
+		case ch == '/':
+			sign = -1
+			r.pos++
+		case ch == '(' || isSymbolStart(ch):
+			// juxtaposition multiplies
+		default:
+			return factors, true
+		}
+		next, ok := r.power()
+		if !ok {
+			return nil, false
+		}
+		factors = mergeFactors(factors, next, sign)
+	}
+}
+
+// power reads an atom with an optional integer exponent
+func (r *labelReader) power() ([]factor, bool) {
+	factors, ok := r.atom()
+	if !ok {
+		return nil, false
+	}
+	r.skipSpace()
+	if r.pos >= len(r.input) || r.input[r.pos] != '^' {
+		return factors, true
+	}
+	r.pos++
+	r.skipSpace()
+	start := r.pos
+	if r.pos < len(r.input) && (r.input[r.pos] == '-' || r.input[r.pos] == '+') {
+		r.pos++
+	}
+	for r.pos < len(r.input) && unicode.IsDigit(r.input[r.pos]) {
+		r.pos++
+	}
+	exp, err := strconv.Atoi(string(r.input[start:r.pos]))
+	if err != nil {
+		return nil, false
+	}
+	for i := range factors {
+		factors[i].power *= exp
+	}
+	return factors, true
+}
+
+// atom reads a symbol, the number 1 or a parenthesised product
+func (r *labelReader) atom() ([]factor, bool) {
+	r.skipSpace()
+	if r.pos >= len(r.input) {
+		return nil, false
+	}
+	switch ch := r.input[r.pos]; {
+	case ch == '(':
+		r.pos++
+		inner, ok := r.product()
+		r.skipSpace()
+		if !ok || r.pos >= len(r.input) || r.input[r.pos] != ')' {
+			return nil, false
+		}
+		r.pos++
+		return inner, true
+
+	case ch == '1' && (r.pos+1 == len(r.input) || !unicode.IsDigit(r.input[r.pos+1]) && r.input[r.pos+1] != '.'):
+		r.pos++
+		return nil, true
+
+	case isSymbolStart(ch):
+		start := r.pos
+		for r.pos < len(r.input) && isSymbolPart(r.input[r.pos]) {
+			r.pos++
+		}
+		return []factor{{symbol: string(r.input[start:r.pos]), power: 1}}, true
+	}
+	return nil, false
+}
+
+// skipSpace advances past whitespace
+func (r *labelReader) skipSpace() {
+	for r.pos < len(r.input) && unicode.IsSpace(r.input[r.pos]) {
+		r.pos++
+	}
+}
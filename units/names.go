@@ -0,0 +1,205 @@
+/*
+Unit Names - Aliases, Plurals and Suggestions
+=============================================
+Part of Axion CLI Calculator
+
+Besides its symbol, a unit can be written with its full name ("mile"), the
+plural of that name ("miles"), any alias ("metre", "°C", "Ω") or a prefixed
+name ("kilometers", "mebibytes"). When nothing matches exactly the lookup
+is retried case-insensitively ("Kg", "KWH", "Miles"), but only when exactly
+one unit fits, so "MM" (mm or Mm) stays an error. Single-letter symbols are
+never case-folded, keeping "M", "S" and "H" free for variables.
+
+When a unit is still unknown, Suggest proposes the closest known spellings
+by edit distance for "did you mean" errors.
+*/
+
+package units
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// keys lists every spelling that resolves to u: symbol, aliases, name and plural
+func (u Unit) keys() []string {
+	keys := []string{u.Symbol}
+	keys = append(keys, u.Aliases...)
+	if u.Name != "" {
+		keys = append(keys, u.Name, plural(u.Name))
+	}
+
+	seen := make(map[string]bool)
+	unique := keys[:0]
+	for _, k := range keys {
+		if k != "" && !seen[k] {
+			seen[k] = true
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
+
+// plural returns the English plural of a unit name
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "foot"):
+		return strings.TrimSuffix(name, "foot") + "feet"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "z"), strings.HasSuffix(name, "x"):
+		return name // hertz, celsius, lux
+	case strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// lookupFold matches symbol case-insensitively against every known spelling,
+// including prefixed ones, and succeeds only if a single unit matches
+func lookupFold(symbol string) (Unit, bool) {
+	if utf8.RuneCountInString(symbol) < 2 {
+		return Unit{}, false
+	}
+
+	var matches []Unit
+	addMatch := func(u Unit) {
+		for _, m := range matches {
+			if m.Factor == u.Factor && m.Offset == u.Offset && m.Dim == u.Dim && m.Absolute == u.Absolute {
+				return
+			}
+		}
+		matches = append(matches, u)
+	}
+
+	for key, u := range index {
+		if strings.EqualFold(key, symbol) {
+			addMatch(u)
+		}
+	}
+
+	// Fold prefixed spellings too, but only onto base spellings of two or
+	// more characters so "Mb" is not silently read as megabytes
+	hasPrefix := func(s, prefix string) bool {
+		return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+	}
+	find := func(key string) (Unit, bool) {
+		if utf8.RuneCountInString(key) < 2 {
+			return Unit{}, false
+		}
+		var found []Unit
+		for k, u := range index {
+			if strings.EqualFold(k, key) && (len(found) == 0 || found[0].Symbol != u.Symbol) {
+				found = append(found, u)
+			}
+		}
+		if len(found) != 1 {
+			return Unit{}, false
+		}
+		return found[0], true
+	}
+	if u, ok := lookupPrefixed(symbol, true, hasPrefix, find); ok {
+		addMatch(u)
+	}
+
+	if len(matches) != 1 {
+		return Unit{}, false
+	}
+	return matches[0], true
+}
+
+// Completions returns the unit spellings starting with prefix in sorted
+// order: registered symbols, names, plurals and aliases (custom units and
+// currencies included) and prefixed symbols such as km or GHz
+func Completions(prefix string) []string {
+	seen := make(map[string]bool)
+	for key, u := range index {
+		if strings.HasPrefix(key, prefix) {
+			seen[key] = true
+		}
+		if key != u.Symbol || u.Prefixes == 0 {
+			continue
+		}
+		for _, p := range prefixes {
+			if symbol := p.Symbol + u.Symbol; p.Symbol != "" && u.Prefixes.accepts(p) && strings.HasPrefix(symbol, prefix) {
+				seen[symbol] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Suggest returns up to three known unit spellings closest to word
+func Suggest(word string) []string {
+	limit := utf8.RuneCountInString(word) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 3 {
+		limit = 3
+	}
+
+	type candidate struct {
+		key      string
+		distance int
+	}
+	var candidates []candidate
+	lower := strings.ToLower(word)
+	for key := range index {
+		if d := editDistance(lower, strings.ToLower(key)); d <= limit && key != word {
+			candidates = append(candidates, candidate{key, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	var result []string
+	for _, c := range candidates {
+		if len(result) == 3 {
+			break
+		}
+		result = append(result, c.key)
+	}
+	return result
+}
+
+// unknownUnit builds the error for an unrecognised unit, with suggestions
+func unknownUnit(symbol string) error {
+	if suggestions := Suggest(symbol); len(suggestions) > 0 {
+		return Errorf("unknown unit %q (did you mean %s?)", symbol, strings.Join(suggestions, ", "))
+	}
+	return Errorf("unknown unit %q", symbol)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
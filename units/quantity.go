@@ -0,0 +1,414 @@
+/*
+Quantity Module - Dimensional Analysis
+======================================
+Part of Axion CLI Calculator
+
+This file implements unit-carrying quantities for the evaluator. Every
+quantity stores its magnitude in SI base units together with a dimension
+vector over the seven SI base dimensions:
+
+    length (m), mass (kg), time (s), current (A),
+    temperature (K), amount (mol), luminosity (cd)
+
+plus three non-SI dimensions that keep otherwise dimensionless units apart:
+
+    angle (rad), information (B), currency (base currency of the rate table)
+
+and a few spare slots that user-defined categories (e.g. story points) can
+claim as new base dimensions.
+
+Multiplication and division add and subtract dimension vectors, addition and
+comparison require identical dimensions, and powers scale the vector. A
+quantity may also carry a display unit (e.g. "km" or "mi/h") so results can
+be shown in the unit the user wrote instead of the SI base unit.
+
+Temperatures read on a scale (20 C, 300 K) are absolute and are kept apart
+from temperature differences (5 deltaC, or the difference of two readings):
+
+    absolute + difference    absolute         20 C + 5 deltaC = 25 C
+    absolute - absolute      difference       300 K - 20 C = 6.85 deltaK
+    absolute + absolute      error
+    difference - absolute    error
+
+Readings on an offset scale (C, F) cannot be negated, scaled, multiplied or
+raised to a power, since their zero point is arbitrary. Readings in K or R
+count from absolute zero and multiply like any other quantity, so ideal gas
+law expressions such as n * R * 300 K keep working.
+*/
+
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Base dimension indices into a Dimension vector
+const (
+	Length = iota
+	Mass
+	Time
+	Current
+	Temperature
+	Amount
+	Luminosity
+	Angle
+	Information
+	Currency
+	numBuiltinDimensions
+)
+
+// maxCustomDimensions is the number of extra base dimensions reserved for
+// user-defined categories (see custom.go)
+const maxCustomDimensions = 4
+
+const numDimensions = numBuiltinDimensions + maxCustomDimensions
+
+// Dimension holds the exponent of each base dimension
+type Dimension [numDimensions]int
+
+// baseSymbol names the base unit of a dimension
+type baseSymbol struct {
+	index  int
+	symbol string
+}
+
+// baseSymbols lists the base unit of each dimension in display order;
+// user-defined base dimensions are appended as they are loaded
+var baseSymbols = []baseSymbol{
+	{Mass, "kg"},
+	{Length, "m"},
+	{Time, "s"},
+	{Current, "A"},
+	{Temperature, "K"},
+	{Amount, "mol"},
+	{Luminosity, "cd"},
+	{Angle, "rad"},
+	{Information, "B"},
+	{Currency, "¤"}, // renamed to the base currency when rates are loaded
+}
+
+// IsZero reports whether the dimension is dimensionless
+func (d Dimension) IsZero() bool {
+	return d == Dimension{}
+}
+
+// String renders the dimension in SI base units (e.g. "kg*m/s^2")
+func (d Dimension) String() string {
+	var num, den []string
+	for _, b := range baseSymbols {
+		exp := d[b.index]
+		switch {
+		case exp == 1:
+			num = append(num, b.symbol)
+		case exp > 1:
+			num = append(num, fmt.Sprintf("%s^%d", b.symbol, exp))
+		case exp == -1:
+			den = append(den, b.symbol)
+		case exp < -1:
+			den = append(den, fmt.Sprintf("%s^%d", b.symbol, -exp))
+		}
+	}
+
+	result := strings.Join(num, "*")
+	if len(den) == 0 {
+		return result
+	}
+	if result == "" {
+		result = "1"
+	}
+	if len(den) == 1 {
+		return result + "/" + den[0]
+	}
+	return result + "/(" + strings.Join(den, "*") + ")"
+}
+
+// ParseDimension reads a dimension written by String; "" is dimensionless
+func ParseDimension(s string) (Dimension, error) {
+	if strings.TrimSpace(s) == "" {
+		return Dimension{}, nil
+	}
+	u, err := Parse(s)
+	if err != nil {
+		return Dimension{}, err
+	}
+	return u.Dim, nil
+}
+
+// Quantity is a numeric value with an associated physical dimension
+type Quantity struct {
+	Value    float64   // Magnitude in SI base units
+	Dim      Dimension // Dimension vector
+	Unit     string    // Optional display unit label
+	Scale    float64   // SI value of one display unit
+	Offset   float64   // SI value of the display unit's zero point
+	Absolute bool      // Absolute temperature reading rather than a difference
+}
+
+// Scalar wraps a plain number as a dimensionless quantity
+func Scalar(v float64) Quantity {
+	return Quantity{Value: v}
+}
+
+// Display returns the value and unit label the quantity should be shown in
+func (q Quantity) Display() (float64, string) {
+	if q.Dim.IsZero() && q.Unit == "" {
+		return q.Value, ""
+	}
+	if q.Unit != "" && q.Scale != 0 {
+		return (q.Value - q.Offset) / q.Scale, q.Unit
+	}
+	return q.Value, q.Dim.String()
+}
+
+// labelled reports whether q carries a display unit that should propagate
+func (q Quantity) labelled() bool {
+	return q.Unit != "" && !q.Dim.IsZero()
+}
+
+// scalable reports whether q's display unit can be combined multiplicatively
+func (q Quantity) scalable() bool {
+	return q.labelled() && q.Offset == 0
+}
+
+// group wraps compound unit labels in parentheses when combined with others
+func group(label string) string {
+	if strings.ContainsAny(label, "*/") {
+		return "(" + label + ")"
+	}
+	return label
+}
+
+// Add sums two quantities of identical dimension, keeping the left display unit
+func Add(a, b Quantity) (Quantity, error) {
+	if a.Dim != b.Dim {
+		return Quantity{}, Errorf("incompatible units: cannot add %s and %s", a.dimName(), b.dimName())
+	}
+	if a.Absolute && b.Absolute {
+		return Quantity{}, Errorf("cannot add two absolute temperatures; add a difference such as 5 deltaC instead")
+	}
+	result := Quantity{Value: a.Value + b.Value, Dim: a.Dim}
+	switch {
+	case a.Absolute:
+		result.keepReading(a)
+	case b.Absolute:
+		result.keepReading(b)
+	default:
+		result.keepLabel(a, b)
+	}
+	return result, result.checkAbsoluteZero()
+}
+
+// Sub subtracts two quantities of identical dimension
+func Sub(a, b Quantity) (Quantity, error) {
+	if a.Dim != b.Dim {
+		return Quantity{}, Errorf("incompatible units: cannot subtract %s from %s", b.dimName(), a.dimName())
+	}
+	result := Quantity{Value: a.Value - b.Value, Dim: a.Dim}
+	switch {
+	case a.Absolute && b.Absolute:
+		result.differenceLabel(a)
+	case a.Absolute:
+		result.keepReading(a)
+	case b.Absolute:
+		return Quantity{}, Errorf("cannot subtract an absolute temperature from a temperature difference")
+	default:
+		result.keepLabel(a, b)
+	}
+	return result, result.checkAbsoluteZero()
+}
+
+// Neg negates a quantity, preserving its unit
+func Neg(a Quantity) (Quantity, error) {
+	if a.onOffsetScale() {
+		return Quantity{}, Errorf("cannot negate an absolute temperature (%s)", a.Unit)
+	}
+	a.Value = -a.Value
+	a.Absolute = false
+	return a, nil
+}
+
+// Mul multiplies two quantities, adding their dimension vectors
+func Mul(a, b Quantity) (Quantity, error) {
+	if err := checkScalable(a, b, "multiply"); err != nil {
+		return Quantity{}, err
+	}
+	result := Quantity{Value: a.Value * b.Value}
+	for i := range result.Dim {
+		result.Dim[i] = a.Dim[i] + b.Dim[i]
+	}
+	switch {
+	case a.scalable() && b.scalable():
+		result.Scale = a.Scale * b.Scale
+		result.Unit = productLabel(a.Unit, b.Unit, 1, result.Dim, result.Scale)
+	case a.scalable() && b.Dim.IsZero():
+		result.Unit, result.Scale = a.Unit, a.Scale
+	case b.scalable() && a.Dim.IsZero():
+		result.Unit, result.Scale = b.Unit, b.Scale
+	}
+	result.Absolute = (a.Absolute && b.Dim.IsZero()) || (b.Absolute && a.Dim.IsZero())
+	result.dropEmptyLabel()
+	return result, nil
+}
+
+// Div divides two quantities, subtracting their dimension vectors
+func Div(a, b Quantity) (Quantity, error) {
+	if err := checkScalable(a, b, "divide"); err != nil {
+		return Quantity{}, err
+	}
+	if b.Value == 0 {
+		return Quantity{}, fmt.Errorf("division by zero")
+	}
+	result := Quantity{Value: a.Value / b.Value}
+	for i := range result.Dim {
+		result.Dim[i] = a.Dim[i] - b.Dim[i]
+	}
+	switch {
+	case a.scalable() && b.scalable():
+		result.Scale = a.Scale / b.Scale
+		result.Unit = productLabel(a.Unit, b.Unit, -1, result.Dim, result.Scale)
+	case a.scalable() && b.Dim.IsZero():
+		result.Unit, result.Scale = a.Unit, a.Scale
+	case b.scalable() && a.Dim.IsZero():
+		result.Unit, result.Scale = "1/"+group(b.Unit), 1/b.Scale
+	}
+	result.Absolute = a.Absolute && b.Dim.IsZero()
+	result.dropEmptyLabel()
+	return result, nil
+}
+
+// Pow raises a quantity to a dimensionless power
+// The resulting dimension exponents must be whole numbers
+func Pow(a Quantity, exp float64) (Quantity, error) {
+	if err := checkScalable(a, Scalar(exp), "raise"); err != nil {
+		return Quantity{}, err
+	}
+	result := Quantity{Value: math.Pow(a.Value, exp)}
+	for i, d := range a.Dim {
+		scaled := float64(d) * exp
+		if scaled != math.Trunc(scaled) {
+			return Quantity{}, Errorf("cannot raise %s to non-integer dimension power %g", a.dimName(), exp)
+		}
+		result.Dim[i] = int(scaled)
+	}
+	if a.scalable() {
+		base := a.Unit
+		if strings.ContainsAny(base, "*/^") {
+			base = "(" + base + ")"
+		}
+		result.Unit = fmt.Sprintf("%s^%g", base, exp)
+		result.Scale = math.Pow(a.Scale, exp)
+	}
+	result.dropEmptyLabel()
+	return result, nil
+}
+
+// Compare returns -1, 0 or 1 after checking both quantities share a
+// dimension; values within rounding error of each other compare equal
+func Compare(a, b Quantity) (int, error) {
+	if a.Dim != b.Dim {
+		return 0, Errorf("incompatible units: cannot compare %s and %s", a.dimName(), b.dimName())
+	}
+	if a.Absolute != b.Absolute {
+		return 0, Errorf("cannot compare an absolute temperature with a temperature difference")
+	}
+	switch {
+	case nearly(a.Value, b.Value):
+		return 0, nil
+	case a.Value < b.Value:
+		return -1, nil
+	case a.Value > b.Value:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// In expresses q in the unit described by target, which must share its dimension
+func In(q, target Quantity, label string) (Quantity, error) {
+	if q.Dim != target.Dim {
+		return Quantity{}, Errorf("cannot convert %s to %s", q.dimName(), label)
+	}
+	if target.Value == 0 {
+		return Quantity{}, Errorf("invalid target unit %s", label)
+	}
+	switch {
+	case q.Absolute && !target.Absolute:
+		return Quantity{}, Errorf("cannot express an absolute temperature in %s, which is a temperature difference", label)
+	case !q.Absolute && target.Offset != 0:
+		return Quantity{}, Errorf("cannot express a temperature difference in %s; use delta%s", label, label)
+	}
+	return Quantity{Value: q.Value, Dim: q.Dim, Unit: label, Scale: target.Value, Offset: target.Offset, Absolute: q.Absolute}, nil
+}
+
+// onOffsetScale reports whether q is a reading on a scale with an arbitrary
+// zero point, such as 20 C
+func (q Quantity) onOffsetScale() bool {
+	return q.Absolute && q.Offset != 0
+}
+
+// checkScalable rejects products, quotients and powers of readings on an
+// offset scale
+func checkScalable(a, b Quantity, verb string) error {
+	for _, q := range []Quantity{a, b} {
+		if q.onOffsetScale() {
+			return Errorf("cannot %s an absolute temperature (%s); convert it to K or use a difference such as delta%s", verb, q.Unit, q.Unit)
+		}
+	}
+	return nil
+}
+
+// checkAbsoluteZero rejects absolute temperatures below absolute zero
+func (q Quantity) checkAbsoluteZero() error {
+	if q.Absolute && q.Value < 0 {
+		value, unit := q.Display()
+		return fmt.Errorf("temperature below absolute zero: %g %s", value, unit)
+	}
+	return nil
+}
+
+// keepReading gives a sum or difference the display unit of the absolute
+// temperature r
+func (q *Quantity) keepReading(r Quantity) {
+	q.Unit, q.Scale, q.Offset, q.Absolute = r.Unit, r.Scale, r.Offset, true
+}
+
+// differenceLabel labels the difference of two readings in the difference
+// unit matching r's scale (deltaC for C), falling back to deltaK
+func (q *Quantity) differenceLabel(r Quantity) {
+	if !r.labelled() {
+		return
+	}
+	if u, ok := Lookup("delta" + r.Unit); ok && !u.Absolute && u.Dim == r.Dim {
+		q.Unit, q.Scale = u.Symbol, u.Factor
+		return
+	}
+	q.Unit, q.Scale = "deltaK", 1
+}
+
+// keepLabel carries the first available display unit into a sum or difference
+func (q *Quantity) keepLabel(a, b Quantity) {
+	if a.labelled() {
+		q.Unit, q.Scale, q.Offset = a.Unit, a.Scale, a.Offset
+	} else if b.labelled() {
+		q.Unit, q.Scale, q.Offset = b.Unit, b.Scale, b.Offset
+	}
+}
+
+// dropEmptyLabel removes display units from dimensionless results
+func (q *Quantity) dropEmptyLabel() {
+	if q.Dim.IsZero() {
+		q.Unit, q.Scale, q.Offset = "", 0, 0
+	}
+}
+
+// dimName describes a quantity's unit for error messages
+func (q Quantity) dimName() string {
+	if q.Dim.IsZero() {
+		return "a plain number"
+	}
+	if q.Unit != "" {
+		return q.Unit
+	}
+	return q.Dim.String()
+}
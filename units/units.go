@@ -2,90 +2,391 @@
 Units Module - Unit Conversion System
 =====================================
 
-This module implements unit conversion functionality for three measurement
-categories: length, weight, and time. All conversions are performed through
-a base unit system where each unit is defined by its conversion factor to
-the base unit of its category.
+This module implements unit conversion across a data-driven registry of
+measurement categories. Every category has a base unit and a dimension
+vector, and every unit is defined by an affine mapping onto the base unit
+of its category.
 
 Conversion methodology:
 1. Convert input value from source unit to base unit
 2. Convert from base unit to target unit
-3. Formula: result = value * (source_factor / target_factor)
+3. Formula: base = value * factor + offset
+            result = (base - target_offset) / target_factor
 
-Base units:
-- Length: meters (m)
-- Weight: kilograms (kg)
-- Time: seconds (s)
+For purely multiplicative units the offset is zero and the formula reduces
+to result = value * (source_factor / target_factor).
+
+Categories and base units:
+- Length: meters (m)          - Speed: meters per second (m/s)
+- Mass: kilograms (kg)        - Force: newtons (N)
+- Time: seconds (s)           - Energy: joules (J)
+- Temperature: kelvin (K)     - Power: watts (W)
+- Area: square meters (m2)    - Pressure: pascals (Pa)
+- Volume: cubic meters (m3)   - Data: bytes (B)
+- Angle: radians (rad)        - Frequency: hertz (Hz)
+
+Temperatures come in two flavours. Absolute temperatures (C, F, K, R) carry
+an offset to absolute zero, while temperature differences (deltaC, deltaF,
+deltaK, deltaR) are pure scale factors. Converting between the two is
+rejected, since "10 C" and "a change of 10 C" are different quantities.
+
+Prefixes are composed automatically rather than listed: any unit marked as
+prefixable accepts the SI prefixes y (1e-24) through Y (1e24), with micro
+written as µ or u, so km, µs, hPa, keV and kWh all resolve. Bytes and bits
+take only the multiplying SI prefixes (kB, GB) plus the IEC binary prefixes
+Ki, Mi, Gi, Ti, Pi and Ei (KiB, Mibit). Non-metric units (ft, mi, min, h, d)
+and powered symbols (m2, m3) take no prefixes. A registered symbol always
+wins over a prefixed reading, so min is minutes rather than milli-inches.
 
 The system prevents cross-category conversions (e.g., meters to kilograms)
-by checking unit category membership before performing calculations.
+by comparing the dimension of both units before performing calculations.
+The same dimensions let units be used directly inside expressions (see
+quantity.go), and let Convert accept compound unit expressions such as
+km/h, kg*m/s^2 or g/cm^3 (see expr.go).
 */
 
 package units
 
 import (
 	"fmt"
+	"math"
+	"strings"
 )
 
-// lengthUnits defines conversion factors to meters (base unit)
-var lengthUnits = map[string]float64{
-	"m":  1,       // meters (base unit)
-	"cm": 0.01,    // centimeters
-	"mm": 0.001,   // millimeters
-	"km": 1000,    // kilometers
-	"in": 0.0254,  // inches
-	"ft": 0.3048,  // feet
-	"yd": 0.9144,  // yards
-	"mi": 1609.34, // miles
-}
-
-// weightUnits defines conversion factors to kilograms (base unit)
-var weightUnits = map[string]float64{
-	"kg":  1,         // kilograms (base unit)
-	"g":   0.001,     // grams
-	"mg":  0.000001,  // milligrams
-	"lb":  0.453592,  // pounds
-	"oz":  0.0283495, // ounces
-	"ton": 1000,      // metric tons
-}
-
-// timeUnits defines conversion factors to seconds (base unit)
-var timeUnits = map[string]float64{
-	"s":   1,     // seconds (base unit)
-	"ms":  0.001, // milliseconds
-	"min": 60,    // minutes
-	"h":   3600,  // hours
-	"d":   86400, // days
+// Unit describes the affine mapping of a unit onto its base unit:
+// base = value * Factor + Offset
+type Unit struct {
+	Symbol   string    // Unit symbol as typed by the user
+	Factor   float64   // Base units per unit
+	Offset   float64   // Base value of the unit's zero point
+	Absolute bool      // Absolute temperature scale rather than a difference
+	Dim      Dimension // Dimension of the base unit
+	Category string    // Name of the category the unit belongs to
+	Prefixes PrefixSet // Prefix families the unit accepts
+	Name     string    // Optional descriptive name (e.g. "rack unit")
+	Aliases  []string  // Alternative symbols that resolve to this unit
 }
 
-// Convert performs unit conversion between compatible units
-// Returns converted value or error for unsupported/incompatible units
-func Convert(value float64, from, to string) (float64, error) {
-	// Attempt length unit conversion
-	if sourceFactor, sourceExists := lengthUnits[from]; sourceExists {
-		if targetFactor, targetExists := lengthUnits[to]; targetExists {
-			// Both units are length units - perform conversion
-			// Formula: value * (source_to_base_ratio / target_to_base_ratio)
-			return value * sourceFactor / targetFactor, nil
-		}
+// PrefixSet selects which families of prefixes a unit may be combined with
+type PrefixSet int
+
+const (
+	PrefixSI     PrefixSet = 1 << iota // All SI prefixes from yocto (y) to yotta (Y)
+	PrefixLarge                        // Only SI prefixes from kilo (k) upward
+	PrefixBinary                       // IEC binary prefixes (Ki, Mi, Gi, ...)
+)
+
+// Prefix is a multiplier that can be written in front of a unit symbol
+type Prefix struct {
+	Symbol string    // Prefix as typed by the user
+	Factor float64   // Multiplier applied to the unit
+	Family PrefixSet // Family the prefix belongs to
+	Name   string    // Spelled-out prefix for full unit names (kilometer)
+}
+
+// prefixes lists every known prefix; µ, μ and u are all accepted for micro
+var prefixes = []Prefix{
+	{"Y", 1e24, PrefixLarge, "yotta"}, {"Z", 1e21, PrefixLarge, "zetta"},
+	{"E", 1e18, PrefixLarge, "exa"}, {"P", 1e15, PrefixLarge, "peta"},
+	{"T", 1e12, PrefixLarge, "tera"}, {"G", 1e9, PrefixLarge, "giga"},
+	{"M", 1e6, PrefixLarge, "mega"}, {"k", 1e3, PrefixLarge, "kilo"},
+	{"h", 1e2, PrefixSI, "hecto"}, {"da", 1e1, PrefixSI, "deca"},
+	{"d", 1e-1, PrefixSI, "deci"}, {"c", 1e-2, PrefixSI, "centi"},
+	{"m", 1e-3, PrefixSI, "milli"}, {"µ", 1e-6, PrefixSI, "micro"},
+	{"μ", 1e-6, PrefixSI, ""}, {"u", 1e-6, PrefixSI, ""},
+	{"n", 1e-9, PrefixSI, "nano"}, {"p", 1e-12, PrefixSI, "pico"},
+	{"f", 1e-15, PrefixSI, "femto"}, {"a", 1e-18, PrefixSI, "atto"},
+	{"z", 1e-21, PrefixSI, "zepto"}, {"y", 1e-24, PrefixSI, "yocto"},
+	{"Ki", 1 << 10, PrefixBinary, "kibi"}, {"Mi", 1 << 20, PrefixBinary, "mebi"},
+	{"Gi", 1 << 30, PrefixBinary, "gibi"}, {"Ti", 1 << 40, PrefixBinary, "tebi"},
+	{"Pi", 1 << 50, PrefixBinary, "pebi"}, {"Ei", 1 << 60, PrefixBinary, "exbi"},
+}
+
+// accepts reports whether a unit with prefix set s may take prefix p
+// Units accepting all SI prefixes also accept the large ones
+func (s PrefixSet) accepts(p Prefix) bool {
+	if p.Family == PrefixLarge {
+		return s&(PrefixSI|PrefixLarge) != 0
 	}
+	return s&p.Family != 0
+}
 
-	// Attempt weight unit conversion
-	if sourceFactor, sourceExists := weightUnits[from]; sourceExists {
-		if targetFactor, targetExists := weightUnits[to]; targetExists {
-			// Both units are weight units - perform conversion
-			return value * sourceFactor / targetFactor, nil
+// Category groups units that share a base unit and dimension
+type Category struct {
+	Name  string    // Display name, e.g. "Length"
+	Dim   Dimension // Dimension shared by every unit in the category
+	Units []Unit    // Units in display order, base unit first
+}
+
+// registry lists every built-in category and its units
+var registry = []Category{
+	{Name: "Length", Dim: Dimension{Length: 1}, Units: []Unit{
+		{Symbol: "m", Factor: 1, Prefixes: PrefixSI, Name: "meter", Aliases: []string{"metre", "metres"}}, // base unit
+		{Symbol: "in", Factor: 0.0254, Name: "inch", Aliases: []string{"″"}},
+		{Symbol: "ft", Factor: 0.3048, Name: "foot", Aliases: []string{"′"}},
+		{Symbol: "yd", Factor: 0.9144, Name: "yard"},
+		{Symbol: "mi", Factor: 1609.34, Name: "mile"},
+	}},
+	{Name: "Mass", Dim: Dimension{Mass: 1}, Units: []Unit{
+		{Symbol: "kg", Factor: 1, Name: "kilogram", Aliases: []string{"kilo", "kilos"}}, // base unit
+		{Symbol: "g", Factor: 0.001, Prefixes: PrefixSI, Name: "gram", Aliases: []string{"gramme", "grammes"}},
+		{Symbol: "lb", Factor: 0.453592, Name: "pound", Aliases: []string{"lbs"}},
+		{Symbol: "oz", Factor: 0.0283495, Name: "ounce"},
+		{Symbol: "ton", Factor: 1000, Name: "tonne", Aliases: []string{"tons"}}, // metric tons
+	}},
+	{Name: "Time", Dim: Dimension{Time: 1}, Units: []Unit{
+		{Symbol: "s", Factor: 1, Prefixes: PrefixSI, Name: "second", Aliases: []string{"sec", "secs"}}, // base unit
+		{Symbol: "min", Factor: 60, Name: "minute", Aliases: []string{"mins"}},
+		{Symbol: "h", Factor: 3600, Name: "hour", Aliases: []string{"hr", "hrs"}},
+		{Symbol: "d", Factor: 86400, Name: "day"},
+		{Symbol: "wk", Factor: 604800, Name: "week"},
+	}},
+	{Name: "Temperature", Dim: Dimension{Temperature: 1}, Units: []Unit{
+		{Symbol: "K", Factor: 1, Absolute: true, Prefixes: PrefixSI, Name: "kelvin"}, // base unit
+		{Symbol: "C", Factor: 1, Offset: 273.15, Absolute: true, Name: "celsius", Aliases: []string{"°C", "℃"}},
+		{Symbol: "F", Factor: 5.0 / 9, Offset: 459.67 * 5 / 9, Absolute: true, Name: "fahrenheit", Aliases: []string{"°F", "℉"}},
+		{Symbol: "R", Factor: 5.0 / 9, Absolute: true, Name: "rankine", Aliases: []string{"°R"}},
+		{Symbol: "deltaK", Factor: 1},       // kelvin difference
+		{Symbol: "deltaC", Factor: 1},       // Celsius difference
+		{Symbol: "deltaF", Factor: 5.0 / 9}, // Fahrenheit difference
+		{Symbol: "deltaR", Factor: 5.0 / 9}, // Rankine difference
+	}},
+	{Name: "Area", Dim: Dimension{Length: 2}, Units: []Unit{
+		{Symbol: "m2", Factor: 1, Aliases: []string{"m²"}}, // square meters (base unit)
+		{Symbol: "cm2", Factor: 1e-4, Aliases: []string{"cm²"}},
+		{Symbol: "km2", Factor: 1e6, Aliases: []string{"km²"}},
+		{Symbol: "ha", Factor: 1e4, Name: "hectare"},
+		{Symbol: "acre", Factor: 4046.8564224}, // international acres
+		{Symbol: "in2", Factor: 6.4516e-4, Aliases: []string{"in²"}},
+		{Symbol: "ft2", Factor: 0.09290304, Aliases: []string{"ft²"}},
+		{Symbol: "yd2", Factor: 0.83612736, Aliases: []string{"yd²"}},
+		{Symbol: "mi2", Factor: 2589988.110336, Aliases: []string{"mi²"}},
+	}},
+	{Name: "Volume", Dim: Dimension{Length: 3}, Units: []Unit{
+		{Symbol: "m3", Factor: 1, Aliases: []string{"m³"}}, // cubic meters (base unit)
+		{Symbol: "L", Factor: 1e-3, Prefixes: PrefixSI, Name: "liter", Aliases: []string{"l", "litre", "litres"}},
+		{Symbol: "cm3", Factor: 1e-6, Aliases: []string{"cm³", "cc"}},
+		{Symbol: "gal", Factor: 3.785411784e-3, Name: "gallon"},                // US gallons
+		{Symbol: "qt", Factor: 9.46352946e-4, Name: "quart"},                   // US quarts
+		{Symbol: "pt", Factor: 4.73176473e-4, Name: "pint"},                    // US pints
+		{Symbol: "cup", Factor: 2.365882365e-4},                                // US cups
+		{Symbol: "floz", Factor: 2.95735295625e-5, Aliases: []string{"fl oz"}}, // US fluid ounces
+		{Symbol: "in3", Factor: 1.6387064e-5, Aliases: []string{"in³"}},
+		{Symbol: "ft3", Factor: 0.028316846592, Aliases: []string{"ft³"}},
+	}},
+	{Name: "Speed", Dim: Dimension{Length: 1, Time: -1}, Units: []Unit{
+		{Symbol: "m/s", Factor: 1}, // meters per second (base unit)
+		{Symbol: "km/h", Factor: 1 / 3.6, Aliases: []string{"kph"}},
+		{Symbol: "mph", Factor: 0.44704},
+		{Symbol: "kn", Factor: 1852.0 / 3600, Name: "knot", Aliases: []string{"kt"}},
+		{Symbol: "ft/s", Factor: 0.3048, Aliases: []string{"fps"}},
+	}},
+	{Name: "Force", Dim: Dimension{Mass: 1, Length: 1, Time: -2}, Units: []Unit{
+		{Symbol: "N", Factor: 1, Prefixes: PrefixSI, Name: "newton"}, // base unit
+		{Symbol: "dyn", Factor: 1e-5, Name: "dyne"},
+		{Symbol: "lbf", Factor: 4.4482216152605}, // pounds-force
+		{Symbol: "kgf", Factor: 9.80665},         // kilograms-force
+	}},
+	{Name: "Energy", Dim: Dimension{Mass: 1, Length: 2, Time: -2}, Units: []Unit{
+		{Symbol: "J", Factor: 1, Prefixes: PrefixSI, Name: "joule"},         // base unit
+		{Symbol: "cal", Factor: 4.184, Prefixes: PrefixSI, Name: "calorie"}, // thermochemical calories
+		{Symbol: "Wh", Factor: 3600, Prefixes: PrefixSI},                    // watt-hours
+		{Symbol: "eV", Factor: 1.602176634e-19, Prefixes: PrefixSI, Name: "electronvolt"},
+		{Symbol: "BTU", Factor: 1055.05585262}, // British thermal units
+	}},
+	{Name: "Power", Dim: Dimension{Mass: 1, Length: 2, Time: -3}, Units: []Unit{
+		{Symbol: "W", Factor: 1, Prefixes: PrefixSI, Name: "watt"}, // base unit
+		{Symbol: "hp", Factor: 745.69987158, Name: "horsepower"},   // mechanical horsepower
+	}},
+	{Name: "Pressure", Dim: Dimension{Mass: 1, Length: -1, Time: -2}, Units: []Unit{
+		{Symbol: "Pa", Factor: 1, Prefixes: PrefixSI, Name: "pascal"}, // base unit
+		{Symbol: "bar", Factor: 1e5, Prefixes: PrefixSI},
+		{Symbol: "psi", Factor: 6894.757293168},
+		{Symbol: "atm", Factor: 101325, Name: "atmosphere"}, // standard atmospheres
+		{Symbol: "mmHg", Factor: 133.322387415},             // millimeters of mercury
+	}},
+	{Name: "Data", Dim: Dimension{Information: 1}, Units: []Unit{
+		{Symbol: "B", Factor: 1, Prefixes: PrefixLarge | PrefixBinary, Name: "byte"}, // base unit
+		{Symbol: "bit", Factor: 0.125, Prefixes: PrefixLarge | PrefixBinary, Aliases: []string{"bits"}},
+	}},
+	{Name: "Angle", Dim: Dimension{Angle: 1}, Units: []Unit{
+		{Symbol: "rad", Factor: 1, Prefixes: PrefixSI, Name: "radian"}, // base unit
+		{Symbol: "deg", Factor: math.Pi / 180, Name: "degree", Aliases: []string{"°"}},
+		{Symbol: "grad", Factor: math.Pi / 200, Name: "gradian", Aliases: []string{"gon"}},
+		{Symbol: "arcmin", Factor: math.Pi / 10800},  // minutes of arc
+		{Symbol: "arcsec", Factor: math.Pi / 648000}, // seconds of arc
+		{Symbol: "rev", Factor: 2 * math.Pi, Name: "revolution", Aliases: []string{"turn", "turns"}},
+	}},
+	{Name: "Frequency", Dim: Dimension{Time: -1}, Units: []Unit{
+		{Symbol: "Hz", Factor: 1, Prefixes: PrefixSI, Name: "hertz"}, // base unit
+		{Symbol: "rpm", Factor: 1.0 / 60},                            // revolutions per minute
+	}},
+	{Name: "Amount", Dim: Dimension{Amount: 1}, Units: []Unit{
+		{Symbol: "mol", Factor: 1, Prefixes: PrefixSI, Name: "mole"}, // base unit
+	}},
+	{Name: "Current", Dim: Dimension{Current: 1}, Units: []Unit{
+		{Symbol: "A", Factor: 1, Prefixes: PrefixSI, Name: "ampere", Aliases: []string{"amp", "amps"}}, // base unit
+	}},
+	{Name: "Voltage", Dim: Dimension{Mass: 1, Length: 2, Time: -3, Current: -1}, Units: []Unit{
+		{Symbol: "V", Factor: 1, Prefixes: PrefixSI, Name: "volt"}, // base unit
+	}},
+	{Name: "Resistance", Dim: Dimension{Mass: 1, Length: 2, Time: -3, Current: -2}, Units: []Unit{
+		{Symbol: "ohm", Factor: 1, Prefixes: PrefixSI, Aliases: []string{"Ω", "ohms"}}, // base unit
+	}},
+}
+
+// index maps every unit symbol to its definition
+var index = make(map[string]Unit)
+
+func init() {
+	for _, c := range registry {
+		for _, u := range c.Units {
+			u.Dim = c.Dim
+			u.Category = c.Name
+			for _, key := range u.keys() {
+				index[key] = u
+			}
 		}
 	}
+}
 
-	// Attempt time unit conversion
-	if sourceFactor, sourceExists := timeUnits[from]; sourceExists {
-		if targetFactor, targetExists := timeUnits[to]; targetExists {
-			// Both units are time units - perform conversion
-			return value * sourceFactor / targetFactor, nil
+// Categories returns the registered unit categories in display order
+func Categories() []Category {
+	return registry
+}
+
+// Lookup returns the definition of the unit with the given symbol or name
+// Registered symbols, names, plurals and aliases always win (so "min" is
+// minutes, "ft" is feet and "mmHg" is millimeters of mercury); otherwise the
+// symbol is split into a prefix and a prefixable unit, preferring the longest
+// prefix ("dam" is decameters, "Gibit" is gibibits, "kilometers" is km).
+// As a last resort the symbol is matched case-insensitively, provided only
+// one unit matches (see names.go).
+func Lookup(symbol string) (Unit, bool) {
+	if u, ok := index[symbol]; ok {
+		return u, true
+	}
+	if u, ok := lookupPrefixed(symbol, false, strings.HasPrefix, func(key string) (Unit, bool) {
+		u, ok := index[key]
+		return u, ok
+	}); ok {
+		return u, true
+	}
+	return lookupFold(symbol)
+}
+
+// lookupPrefixed splits symbol into a prefix (symbol or spelled-out name) and
+// a prefixable unit, using hasPrefix and find to match each part. Spelled-out
+// names are reported with their canonical symbol (kilometers → km), as are
+// all matches when canonical is set.
+func lookupPrefixed(symbol string, canonical bool, hasPrefix func(s, prefix string) bool, find func(key string) (Unit, bool)) (Unit, bool) {
+	var best Unit
+	found := 0
+	for _, p := range prefixes {
+		for _, written := range []string{p.Symbol, p.Name} {
+			if written == "" || len(written) <= found || len(symbol) <= len(written) || !hasPrefix(symbol, written) {
+				continue
+			}
+			rest := symbol[len(written):]
+			if written == p.Name && len(rest) < 3 {
+				continue // spelled-out prefixes only combine with unit names
+			}
+			base, ok := find(rest)
+			if !ok || !base.Prefixes.accepts(p) {
+				continue
+			}
+			best = base
+			best.Symbol = symbol
+			if canonical || written == p.Name {
+				best.Symbol = p.Symbol + base.Symbol
+			}
+			best.Factor = p.Factor * base.Factor
+			best.Prefixes = 0
+			best.Name, best.Aliases = "", nil
+			found = len(written)
 		}
 	}
+	return best, found > 0
+}
+
+// Prefixes returns the known unit prefixes
+func Prefixes() []Prefix {
+	return prefixes
+}
+
+// describeDim names a unit's category and base dimension for error messages
+func describeDim(u Unit) string {
+	if u.Dim.IsZero() {
+		return "dimensionless"
+	}
+	if u.Category != "" {
+		return fmt.Sprintf("%s (%s)", strings.ToLower(u.Category), u.Dim)
+	}
+	return u.Dim.String()
+}
+
+// IsUnit reports whether symbol names a known unit
+func IsUnit(symbol string) bool {
+	_, ok := Lookup(symbol)
+	return ok
+}
+
+// Quantity returns one of the unit as a quantity, labelled with its symbol
+// Offsets are ignored; use Reading for values on an offset scale
+func (u Unit) Quantity() Quantity {
+	return Quantity{Value: u.Factor, Dim: u.Dim, Unit: u.Symbol, Scale: u.Factor, Offset: u.Offset, Absolute: u.Absolute}
+}
+
+// Reading interprets a plain number as a reading on the unit's scale,
+// applying the unit's offset (e.g. 20 C is 293.15 K)
+func (u Unit) Reading(v Quantity) (Quantity, error) {
+	if !v.Dim.IsZero() {
+		return Quantity{}, Errorf("cannot apply %s to a quantity in %s", u.Symbol, v.dimName())
+	}
+	base := v.Value*u.Factor + u.Offset
+	if u.Absolute && base < 0 {
+		return Quantity{}, fmt.Errorf("temperature below absolute zero: %g %s", v.Value, u.Symbol)
+	}
+	return Quantity{Value: base, Dim: u.Dim, Unit: u.Symbol, Scale: u.Factor, Offset: u.Offset, Absolute: u.Absolute}, nil
+}
+
+// Convert performs unit conversion between compatible units
+// Either side may be a compound unit expression such as km/h or kg*m/s^2
+// Returns converted value or error for unknown/incompatible units
+func Convert(value float64, from, to string) (float64, error) {
+	source, sourceErr := Parse(from)
+	target, targetErr := Parse(to)
+	switch {
+	case sourceErr != nil && targetErr == nil:
+		return 0, Errorf("%v; %s is %s", sourceErr, to, describeDim(target))
+	case targetErr != nil && sourceErr == nil:
+		return 0, Errorf("%v; %s is %s", targetErr, from, describeDim(source))
+	case sourceErr != nil:
+		return 0, sourceErr
+	}
+
+	// Units must share a dimension
+	if source.Dim != target.Dim {
+		return 0, Errorf("dimension mismatch: %s is %s but %s is %s",
+			from, describeDim(source), to, describeDim(target))
+	}
+
+	// Absolute temperatures and temperature differences are not interchangeable
+	if source.Absolute != target.Absolute {
+		return 0, Errorf("cannot convert between absolute temperature and temperature difference: %s → %s", from, to)
+	}
+
+	base := value*source.Factor + source.Offset
+	if source.Absolute && base < 0 {
+		return 0, fmt.Errorf("temperature below absolute zero: %g %s", value, from)
+	}
+
+	// Purely multiplicative units
+	// Formula: value * (source_to_base_ratio / target_to_base_ratio)
+	if source.Offset == 0 && target.Offset == 0 {
+		return value * source.Factor / target.Factor, nil
+	}
 
-	// No valid conversion found - units are either unknown or incompatible
-	return 0, fmt.Errorf("unsupported conversion: %s → %s", from, to)
+	// Affine units: go through the base unit explicitly
+	return (base - target.Offset) / target.Factor, nil
 }
@@ -1,7 +1,11 @@
 package units
 
 import (
+	"Axion/tokenizer"
+	"encoding/json"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -117,3 +121,831 @@ func TestConvert_LargeNumbers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1e9, got)
 }
+
+func TestQuantity_Arithmetic(t *testing.T) {
+	kmUnit, _ := Lookup("km")
+	mUnit, _ := Lookup("m")
+	hUnit, _ := Lookup("h")
+	km, m, h := kmUnit.Quantity(), mUnit.Quantity(), hUnit.Quantity()
+
+	fiveKm, err := Mul(Scalar(5), km)
+	assert.NoError(t, err)
+	threeHundredM, err := Mul(Scalar(300), m)
+	assert.NoError(t, err)
+	sum, err := Add(fiveKm, threeHundredM)
+	assert.NoError(t, err)
+	value, unit := sum.Display()
+	assert.InDelta(t, 5.3, value, 1e-12)
+	assert.Equal(t, "km", unit)
+
+	speed, err := Div(km, h)
+	assert.NoError(t, err)
+	assert.Equal(t, Dimension{Length: 1, Time: -1}, speed.Dim)
+	assert.InDelta(t, 1000.0/3600, speed.Value, 1e-12)
+
+	area, err := Pow(m, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, Dimension{Length: 2}, area.Dim)
+
+	_, err = Add(km, h)
+	assert.Error(t, err)
+	_, err = Pow(m, 0.5)
+	assert.Error(t, err)
+	_, err = Compare(km, h)
+	assert.Error(t, err)
+}
+
+func TestQuantity_Labels(t *testing.T) {
+	// reading returns value in the unit described by label
+	reading := func(value float64, label string) Quantity {
+		u, err := Parse(label)
+		assert.NoError(t, err, label)
+		return Quantity{Value: value * u.Factor, Dim: u.Dim, Unit: label, Scale: u.Factor}
+	}
+
+	root, err := Pow(reading(4, "m^2"), 0.5) // labelled (m^2)^0.5
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		a, b     Quantity
+		divide   bool
+		expected string
+	}{
+		{"cancelled factor", reading(3, "m/s"), reading(2, "s"), false, "m"},
+		{"kept quotient", reading(5, "km"), reading(2, "h"), true, "km/h"},
+		{"product", reading(3, "N"), reading(2, "m"), false, "N*m"},
+		{"square", reading(2, "m"), reading(3, "m"), false, "m^2"},
+		{"lowered power", reading(6, "m^2"), reading(2, "m"), true, "m"},
+		{"nested quotient", reading(4, "m/s"), reading(2, "s"), true, "m/s^2"},
+		{"reciprocal", reading(2, "Hz"), reading(3, "m"), false, "Hz*m"},
+		{"numeric factor kept", reading(2, "L/(100 km)"), reading(50, "km"), false, "L/(100 km)*km"},
+		{"fallback to SI", root, reading(2, "s"), false, ""},
+		{"same dimension", reading(10, "km"), reading(5, "m"), true, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var q Quantity
+			var err error
+			if tt.divide {
+				q, err = Div(tt.a, tt.b)
+			} else {
+				q, err = Mul(tt.a, tt.b)
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, q.Unit)
+		})
+	}
+}
+
+func TestDimension_String(t *testing.T) {
+	tests := []struct {
+		dim      Dimension
+		expected string
+	}{
+		{Dimension{}, ""},
+		{Dimension{Length: 1}, "m"},
+		{Dimension{Length: 1, Time: -1}, "m/s"},
+		{Dimension{Mass: 1, Length: 1, Time: -2}, "kg*m/s^2"},
+		{Dimension{Time: -1}, "1/s"},
+		{Dimension{Mass: 1, Time: -3, Current: -1}, "kg/(s^3*A)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.dim.String())
+		})
+	}
+}
+
+func TestConvert_Temperature(t *testing.T) {
+	tests := []struct {
+		from, to string
+		value    float64
+		expected float64
+	}{
+		{"C", "F", 100, 212},
+		{"F", "C", 32, 0},
+		{"C", "K", 0, 273.15},
+		{"K", "C", 0, -273.15},
+		{"F", "K", -459.67, 0},
+		{"R", "F", 491.67, 32},
+		{"K", "R", 100, 180},
+		{"C", "F", -40, -40},
+		{"deltaC", "deltaF", 10, 18},
+		{"deltaF", "deltaK", 9, 5},
+		{"deltaR", "deltaC", 1.8, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, got, 1e-9)
+		})
+	}
+}
+
+func TestConvert_TemperatureInvalid(t *testing.T) {
+	tests := []struct {
+		from, to string
+		value    float64
+	}{
+		{"C", "deltaF", 10}, // absolute to difference
+		{"deltaC", "K", 10}, // difference to absolute
+		{"C", "m", 10},      // cross-category
+		{"C", "F", -300},    // below absolute zero
+		{"K", "R", -1},      // below absolute zero
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			_, err := Convert(tt.value, tt.from, tt.to)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConvert_Categories(t *testing.T) {
+	tests := []struct {
+		from, to string
+		value    float64
+		expected float64
+	}{
+		{"ha", "acre", 1, 2.4710538146717},
+		{"km2", "m2", 1, 1e6},
+		{"gal", "L", 1, 3.785411784},
+		{"floz", "mL", 1, 29.5735295625},
+		{"fl oz", "mL", 1, 29.5735295625},
+		{"mL", "fl oz", 29.5735295625, 1},
+		{"km/h", "m/s", 36, 10},
+		{"mph", "km/h", 60, 96.56064},
+		{"kgf", "N", 1, 9.80665},
+		{"kWh", "J", 1, 3.6e6},
+		{"kcal", "kJ", 1, 4.184},
+		{"hp", "W", 1, 745.69987158},
+		{"atm", "Pa", 1, 101325},
+		{"bar", "psi", 1, 14.503773773},
+		{"B", "bit", 1, 8},
+		{"GiB", "MiB", 1, 1024},
+		{"GB", "MB", 1, 1000},
+		{"rev", "deg", 1, 360},
+		{"deg", "arcmin", 1, 60},
+		{"kHz", "Hz", 2, 2000},
+		{"rpm", "Hz", 60, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, got, 1e-6)
+		})
+	}
+}
+
+func TestCategories(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range Categories() {
+		assert.NotEmpty(t, c.Units, "category %s has no units", c.Name)
+		assert.Equal(t, 1.0, c.Units[0].Factor, "first unit of %s must be the base unit", c.Name)
+		for _, u := range c.Units {
+			for _, key := range u.keys() {
+				assert.False(t, seen[key], "duplicate unit spelling %s", key)
+				seen[key] = true
+			}
+
+			found, ok := Lookup(u.Symbol)
+			assert.True(t, ok)
+			assert.Equal(t, c.Name, found.Category)
+			assert.Equal(t, c.Dim, found.Dim)
+		}
+	}
+
+	_, err := Convert(1, "J", "W")
+	assert.Error(t, err, "energy and power must not convert")
+	_, err = Convert(1, "B", "m")
+	assert.Error(t, err, "data and length must not convert")
+}
+
+func TestLookup_Prefixes(t *testing.T) {
+	tests := []struct {
+		symbol   string
+		factor   float64
+		category string
+	}{
+		{"km", 1e3, "Length"},
+		{"um", 1e-6, "Length"},
+		{"µm", 1e-6, "Length"},
+		{"nm", 1e-9, "Length"},
+		{"dam", 10, "Length"},
+		{"mg", 1e-6, "Mass"},
+		{"ms", 1e-3, "Time"},
+		{"mL", 1e-6, "Volume"},
+		{"hPa", 100, "Pressure"},
+		{"mbar", 100, "Pressure"},
+		{"keV", 1.602176634e-16, "Energy"},
+		{"kWh", 3.6e6, "Energy"},
+		{"GB", 1e9, "Data"},
+		{"MiB", 1 << 20, "Data"},
+		{"Gibit", 1 << 27, "Data"},
+		{"mrad", 1e-3, "Angle"},
+		{"min", 60, "Time"},
+		{"ft", 0.3048, "Length"},
+		{"mmHg", 133.322387415, "Pressure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			u, ok := Lookup(tt.symbol)
+			assert.True(t, ok)
+			assert.Equal(t, tt.symbol, u.Symbol)
+			assert.Equal(t, tt.category, u.Category)
+			assert.InEpsilon(t, tt.factor, u.Factor, 1e-12)
+		})
+	}
+
+	for _, symbol := range []string{"Kim", "kmin", "kft", "mh", "cm4", "mB", "kdeltaC", "kkm"} {
+		assert.False(t, IsUnit(symbol), "%s must not be a unit", symbol)
+	}
+}
+
+func TestConvert_Compound(t *testing.T) {
+	tests := []struct {
+		from, to string
+		value    float64
+		expected float64
+	}{
+		{"km/h", "m/s", 100, 27.7777778},
+		{"kg*m/s^2", "N", 9.81, 9.81},
+		{"g/cm^3", "kg/m^3", 1, 1000},
+		{"kg * m / s^2", "N", 1, 1},
+		{"N m", "J", 5, 5},
+		{"N·m", "J", 5, 5},
+		{"m/s/s", "m*s^-2", 3, 3},
+		{"(m/s)^2", "J/kg", 4, 4},
+		{"1/s", "Hz", 50, 50},
+		{"ft/s", "ft*s^-1", 1, 1},
+		{"kWh/km", "J/m", 1, 3600},
+		{"L/(100 km)", "mL/m", 8, 0.08},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, got, 1e-6)
+		})
+	}
+}
+
+func TestConvert_CompoundInvalid(t *testing.T) {
+	tests := []struct {
+		from, to string
+		message  string
+	}{
+		{"m/s", "kg", "dimension mismatch"},
+		{"kg*m/s^2", "J", "dimension mismatch"},
+		{"foo/s", "m/s", "unknown unit"},
+		{"m/(s", "m/s", "missing closing parenthesis"},
+		{"m^x", "m", "exponent must be an integer"},
+		{"C/s", "K/s", "offset scale"},
+		{"m/", "m", "unexpected end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			_, err := Convert(1, tt.from, tt.to)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+// withRegistry restores the built-in registry after a test loads custom units
+func withRegistry(t *testing.T) {
+	savedRegistry := make([]Category, len(registry))
+	for i, c := range registry {
+		c.Units = append([]Unit(nil), c.Units...)
+		savedRegistry[i] = c
+	}
+	savedIndex := make(map[string]Unit, len(index))
+	for k, v := range index {
+		savedIndex[k] = v
+	}
+	savedSymbols := append([]baseSymbol(nil), baseSymbols...)
+	savedDimensions := customDimensions
+	savedLoaded := loaded
+	savedRates := currentRates
+	savedPhrases := tokenizer.UnitPhrases
+	t.Cleanup(func() {
+		registry, index, baseSymbols, customDimensions = savedRegistry, savedIndex, savedSymbols, savedDimensions
+		loaded = savedLoaded
+		currentRates = savedRates
+		tokenizer.UnitPhrases = savedPhrases
+	})
+}
+
+func TestLoad_CustomUnits(t *testing.T) {
+	withRegistry(t)
+
+	n, err := load(unitFile{
+		Units: []fileUnit{
+			{Symbol: "U", Name: "rack unit", Definition: "1.75 in", Aliases: []string{"RU"}},
+			{Symbol: "sprint", Definition: "2 wk"},
+			{Symbol: "quarter", Definition: "6 sprint"}, // refers to a unit defined above
+			{Symbol: "mpg", Definition: "mi/gal", Category: "Fuel Economy"},
+			{Symbol: "Re", Definition: "1.25 K", Offset: 273.15},
+		},
+		Categories: []fileCategory{
+			{Name: "Effort", Units: []fileUnit{
+				{Symbol: "epic", Definition: "20 sp"}, // forward reference
+				{Symbol: "sp", Name: "story point", Prefixes: "si"},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, n)
+
+	tests := []struct {
+		from, to string
+		value    float64
+		expected float64
+	}{
+		{"U", "cm", 42, 186.69},
+		{"RU", "U", 1, 1},
+		{"quarter", "d", 1, 84},
+		{"mpg", "km/L", 1, 0.4251427},
+		{"Re", "C", 80, 100},
+		{"epic", "sp", 2, 40},
+		{"ksp", "epic", 1, 50},
+		{"sp/sprint", "sp/d", 14, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, got, 1e-6)
+		})
+	}
+
+	u, ok := Lookup("U")
+	assert.True(t, ok)
+	assert.Equal(t, "Length", u.Category)
+	assert.Equal(t, "rack unit", u.Name)
+	assert.Subset(t, tokenizer.UnitPhrases, []string{"rack unit", "rack units", "story point", "story points"})
+	assert.Equal(t, "sp", Dimension{numBuiltinDimensions: 1}.String())
+	assert.NotNil(t, findCategory("Fuel Economy"))
+
+	_, err = Convert(1, "sp", "h")
+	assert.ErrorContains(t, err, "dimension mismatch")
+}
+
+func TestLoadCustom(t *testing.T) {
+	withRegistry(t)
+	before := len(loaded)
+
+	var saved []json.RawMessage
+	t.Run("previous session", func(t *testing.T) {
+		withRegistry(t)
+		_, err := load(unitFile{Units: []fileUnit{{Symbol: "sprint", Definition: "2 wk"}}})
+		assert.NoError(t, err)
+		saved = Custom()
+	})
+	_, ok := Lookup("sprint")
+	assert.False(t, ok)
+
+	n, err := LoadCustom(saved)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, err := Convert(1, "sprint", "d")
+	assert.NoError(t, err)
+	assert.Equal(t, 14.0, got)
+
+	// Files already loaded are skipped
+	n, err = LoadCustom(saved)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Len(t, Custom(), before+1)
+}
+
+func TestLoad_CustomUnitsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    unitFile
+		message string
+	}{
+		{"builtin clash", unitFile{Units: []fileUnit{{Symbol: "ft", Definition: "0.3 m"}}}, "already defined"},
+		{"prefixed clash", unitFile{Units: []fileUnit{{Symbol: "km", Definition: "1000 m"}}}, "already defined"},
+		{"alias clash", unitFile{Units: []fileUnit{{Symbol: "U", Definition: "1.75 in", Aliases: []string{"h"}}}}, "already defined"},
+		{"duplicate", unitFile{Units: []fileUnit{{Symbol: "x1", Definition: "m"}, {Symbol: "x1", Definition: "s"}}}, "defined twice"},
+		{"cycle", unitFile{Units: []fileUnit{
+			{Symbol: "foo", Definition: "2 bar2"},
+			{Symbol: "bar2", Definition: "3 foo"},
+		}}, "cycle in unit definitions: foo → bar2 → foo"},
+		{"self reference", unitFile{Units: []fileUnit{{Symbol: "loop", Definition: "2 loop"}}}, "cycle"},
+		{"missing definition", unitFile{Units: []fileUnit{{Symbol: "thing"}}}, "needs a definition"},
+		{"unknown unit", unitFile{Units: []fileUnit{{Symbol: "thing", Definition: "3 widgets"}}}, "unknown unit"},
+		{"no category", unitFile{Units: []fileUnit{{Symbol: "odd", Definition: "m*kg"}}}, "matches no category"},
+		{"wrong category", unitFile{Units: []fileUnit{{Symbol: "odd", Definition: "m", Category: "Mass"}}}, "does not match category"},
+		{"category clash", unitFile{Categories: []fileCategory{{Name: "length", Units: []fileUnit{{Symbol: "zz"}}}}}, "already defined"},
+		{"two bases", unitFile{Categories: []fileCategory{{Name: "Effort", Units: []fileUnit{{Symbol: "sp"}, {Symbol: "tsk"}}}}}, "more than one base unit"},
+		{"bad symbol", unitFile{Units: []fileUnit{{Symbol: "rack unit", Definition: "1.75 in"}}}, "invalid unit symbol"},
+		{"bad prefixes", unitFile{Units: []fileUnit{{Symbol: "zz", Definition: "m", Prefixes: "all"}}}, "unknown prefix set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRegistry(t)
+			before := len(index)
+			_, err := load(tt.file)
+			assert.ErrorContains(t, err, tt.message)
+			assert.Equal(t, before, len(index), "failed load must not change the registry")
+		})
+	}
+}
+
+func TestLookup_Names(t *testing.T) {
+	tests := []struct {
+		input  string
+		symbol string
+	}{
+		{"miles", "mi"},
+		{"mile", "mi"},
+		{"feet", "ft"},
+		{"inches", "in"},
+		{"metres", "m"},
+		{"hertz", "Hz"},
+		{"kilometers", "km"},
+		{"milliseconds", "ms"},
+		{"mebibytes", "MiB"},
+		{"Kg", "kg"},
+		{"KWH", "kWh"},
+		{"Miles", "mi"},
+		{"°C", "C"},
+		{"℉", "F"},
+		{"°", "deg"},
+		{"Ω", "ohm"},
+		{"kΩ", "kΩ"},
+		{"µs", "µs"},
+		{"μs", "μs"},
+		{"m²", "m2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			u, ok := Lookup(tt.input)
+			assert.True(t, ok)
+			assert.Equal(t, tt.symbol, u.Symbol)
+		})
+	}
+
+	// Ambiguous or single-letter case folds are rejected
+	for _, input := range []string{"MM", "S", "H", "Mb", "kilom"} {
+		assert.False(t, IsUnit(input), "%s must not be a unit", input)
+	}
+}
+
+func TestConvert_Names(t *testing.T) {
+	got, err := Convert(3, "miles", "meters")
+	assert.NoError(t, err)
+	assert.InDelta(t, 4828.02, got, 1e-6)
+
+	got, err = Convert(5, "Kg", "lb")
+	assert.NoError(t, err)
+	assert.InDelta(t, 11.0231, got, 1e-4)
+
+	got, err = Convert(20, "°C", "°F")
+	assert.NoError(t, err)
+	assert.InDelta(t, 68, got, 1e-9)
+}
+
+func TestConvert_Suggestions(t *testing.T) {
+	_, err := Convert(3, "miless", "meters")
+	assert.ErrorContains(t, err, `unknown unit "miless" (did you mean miles, mile, moles?)`)
+	assert.ErrorContains(t, err, "meters is length (m)")
+
+	_, err = Convert(3, "mi", "meterz")
+	assert.ErrorContains(t, err, "did you mean meter")
+	assert.ErrorContains(t, err, "mi is length (m)")
+
+	_, err = Convert(3, "qwertyuiop", "m")
+	assert.EqualError(t, err, `unknown unit "qwertyuiop"; m is length (m)`)
+
+	assert.Equal(t, []string{"foot"}, Suggest("fooot"))
+	assert.Empty(t, Suggest("zzzzzz"))
+}
+
+func TestPlural(t *testing.T) {
+	tests := map[string]string{
+		"meter": "meters",
+		"foot":  "feet",
+		"inch":  "inches",
+		"hertz": "hertz",
+		"day":   "days",
+		"story": "stories",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, plural(name))
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   float64
+		from    string
+		targets []string
+		want    []Part
+	}{
+		{"height", 1.75, "m", []string{"ft", "in"}, []Part{{5, "ft"}, {8.897637795, "in"}}},
+		{"targets sorted", 1.75, "m", []string{"in", "ft"}, []Part{{5, "ft"}, {8.897637795, "in"}}},
+		{"duration", 93784, "s", []string{"d", "h", "min", "s"}, []Part{{1, "d"}, {2, "h"}, {3, "min"}, {4, "s"}}},
+		{"exact multiple", 2, "ft", []string{"ft", "in"}, []Part{{2, "ft"}, {0, "in"}}},
+		{"negative", -1.75, "m", []string{"ft", "in"}, []Part{{-5, "ft"}, {8.897637795, "in"}}},
+		{"weight", 70, "kg", []string{"lb", "oz"}, []Part{{154, "lb"}, {5.179351, "oz"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Breakdown(tt.value, tt.from, tt.targets)
+			assert.NoError(t, err)
+			assert.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Unit, got[i].Unit)
+				assert.InDelta(t, tt.want[i].Value, got[i].Value, 1e-5)
+			}
+		})
+	}
+
+	_, err := Breakdown(1, "m", []string{"ft", "kg"})
+	assert.ErrorContains(t, err, "dimension mismatch")
+	_, err = Breakdown(20, "C", []string{"K"})
+	assert.ErrorContains(t, err, "offset scale")
+}
+
+func TestAuto(t *testing.T) {
+	tests := []struct {
+		value float64
+		from  string
+		want  []Part
+	}{
+		{93784, "s", []Part{{1, "d"}, {2, "h"}, {3, "min"}, {4, "s"}}},
+		{90, "s", []Part{{1, "min"}, {30, "s"}}},
+		{3, "h", []Part{{3, "h"}}},
+		{0.002, "s", []Part{{2, "ms"}}},
+		{1500, "m", []Part{{1.5, "km"}}},
+		{0.0005, "km", []Part{{500, "mm"}}},
+		{30, "in", []Part{{2.5, "ft"}}},
+		{5280, "ft", []Part{{1, "mi"}}},
+		{5, "kg", []Part{{5, "kg"}}},
+		{3e6, "B", []Part{{3, "MB"}}},
+		{2048, "KiB", []Part{{2, "MiB"}}},
+		{20, "C", []Part{{20, "C"}}},
+		{100, "km/h", []Part{{100, "km/h"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			got, err := Auto(tt.value, tt.from)
+			assert.NoError(t, err)
+			assert.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Unit, got[i].Unit)
+				assert.InDelta(t, tt.want[i].Value, got[i].Value, 1e-5)
+			}
+		})
+	}
+}
+
+func TestAuto_PreferredSystem(t *testing.T) {
+	t.Cleanup(func() { PreferredSystem = "" })
+	tests := []struct {
+		system string
+		value  float64
+		from   string
+		want   Part
+	}{
+		{"imperial", 1609.344, "m", Part{1, "mi"}},
+		{"imperial", 30, "cm", Part{11.811024, "in"}},
+		{"imperial", 2, "kg", Part{4.409245, "lb"}},
+		{"metric", 5280, "ft", Part{1.609344, "km"}},
+		{"metric", 16, "oz", Part{453.59237, "g"}},
+		{"metric", 1, "gal", Part{3.785412, "L"}},
+		{"metric", 1500, "m", Part{1.5, "km"}},
+		{"imperial", 12, "in", Part{1, "ft"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.system+" "+tt.from, func(t *testing.T) {
+			PreferredSystem = tt.system
+			got, err := Auto(tt.value, tt.from)
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.Equal(t, tt.want.Unit, got[0].Unit)
+			assert.InEpsilon(t, tt.want.Value, got[0].Value, 1e-5)
+		})
+	}
+}
+
+func TestRates(t *testing.T) {
+	withRegistry(t)
+
+	r, err := parseRatesJSON([]byte(`{"base": "usd", "date": "2026-10-01", "rates": {"EUR": 0.92, "gbp": 0.79}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", r.Base)
+	assert.Equal(t, []string{"EUR", "GBP", "USD"}, r.Codes())
+	assert.NoError(t, SetRates(r))
+
+	tests := []struct {
+		from, to string
+		value    float64
+		expected float64
+	}{
+		{"USD", "EUR", 100, 92},
+		{"EUR", "USD", 92, 100},
+		{"EUR", "GBP", 100, 85.869565}, // cross rate via USD
+		{"USD/h", "EUR/d", 10, 220.8},  // compound currency units
+		{"usd", "eur", 1, 0.92},        // case-insensitive codes
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, got, 1e-6)
+		})
+	}
+	assert.Equal(t, "USD", Dimension{Currency: 1}.String())
+
+	// A new table replaces the old one
+	r, err = parseRatesCSV([]byte("date,base,currency,rate\n2026-10-08,EUR,USD,1.1\n2026-10-08,EUR,CHF,0.95\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, SetRates(r))
+	assert.False(t, IsUnit("GBP"))
+	got, err := Convert(1.1, "USD", "CHF")
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.95, got, 1e-9)
+	assert.Equal(t, "2026-10-08", CurrentRates().Date)
+
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	assert.True(t, r.Stale(now, 7*24*time.Hour))
+	assert.False(t, r.Stale(now, 30*24*time.Hour))
+}
+
+func TestRates_Invalid(t *testing.T) {
+	tests := []struct {
+		name, data string
+		csv        bool
+		message    string
+	}{
+		{"bad base", `{"base": "US", "date": "2026-10-01", "rates": {"EUR": 0.9}}`, false, "invalid base currency"},
+		{"bad date", `{"base": "USD", "date": "01/10/2026", "rates": {"EUR": 0.9}}`, false, "invalid rates date"},
+		{"no rates", `{"base": "USD", "date": "2026-10-01"}`, false, "no rates"},
+		{"negative rate", `{"base": "USD", "date": "2026-10-01", "rates": {"EUR": -1}}`, false, "invalid rate"},
+		{"bad code", `{"base": "USD", "date": "2026-10-01", "rates": {"EURO": 0.9}}`, false, "invalid currency code"},
+		{"base not one", `{"base": "USD", "date": "2026-10-01", "rates": {"USD": 2}}`, false, "must be 1"},
+		{"mixed dates", "2026-10-01,USD,EUR,0.9\n2026-10-02,USD,GBP,0.8\n", true, "share one date"},
+		{"bad csv rate", "2026-10-01,USD,EUR,abc\n", true, "invalid rate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.csv {
+				_, err = parseRatesCSV([]byte(tt.data))
+			} else {
+				_, err = parseRatesJSON([]byte(tt.data))
+			}
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+
+	withRegistry(t)
+	err := SetRates(Rates{Base: "USD", Date: "2026-10-01", Rates: map[string]float64{"BTU": 2}})
+	assert.ErrorContains(t, err, "clashes with unit")
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value     float64
+		mode      string
+		precision int
+		want      string
+	}{
+		{12345.678, "auto", 4, "1.235e+04"},
+		{12345.678, "fixed", 4, "12345.6780"},
+		{12345.678, "sig", 4, "12350"},
+		{12345.678, "sci", 4, "1.235e4"},
+		{12345.678, "eng", 4, "12.35e3"},
+		{12345.678, "si", 4, "12.35 k"},
+		{3.14159, "fixed", 2, "3.14"},
+		{2, "fixed", 0, "2"},
+		{0.000123, "sig", 2, "0.00012"},
+		{1e20, "sig", 3, "1.00e20"},
+		{0.5, "sig", 3, "0.500"},
+		{0, "sig", 3, "0.00"},
+		{-0.0042, "eng", 3, "-4.20e-3"},
+		{0.0042, "si", 3, "4.20 m"},
+		{999.96, "eng", 4, "1.000e3"},
+		{1e30, "si", 3, "1.00e30"},
+		{42, "sci", 1, "4e1"},
+		{math.Inf(1), "fixed", 2, "+∞"},
+		{math.NaN(), "sci", 2, "NaN"},
+		{1.5, "unknown", 6, "1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Format(tt.value, tt.mode, tt.precision))
+		})
+	}
+}
+
+func TestFormatWithUnit(t *testing.T) {
+	tests := []struct {
+		value     float64
+		unit      string
+		mode      string
+		wantValue string
+		wantUnit  string
+	}{
+		{1500, "m", "si", "1.50", "km"},
+		{0.0012, "kg", "si", "1.20", "g"},
+		{2500, "g", "si", "2.50", "kg"},
+		{0.5, "km", "si", "500", "m"},
+		{1500, "m/s", "si", "1500", "m/s"},
+		{5279, "ft", "si", "5280", "ft"},
+		{20, "C", "si", "20.0", "C"},
+		{1500, "m", "eng", "1.50e3", "m"},
+		{1500, "m", "fixed", "1500.000", "m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit+" "+tt.mode, func(t *testing.T) {
+			value, unit := FormatWithUnit(tt.value, tt.unit, tt.mode, 3)
+			assert.Equal(t, tt.wantValue, value)
+			assert.Equal(t, tt.wantUnit, unit)
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		number string
+		locale string
+		want   string
+	}{
+		{"1234567.891", "plain", "1234567.891"},
+		{"1234567.891", "en", "1,234,567.891"},
+		{"1234567.891", "de", "1.234.567,891"},
+		{"1234567.891", "fr", "1\u202f234\u202f567,891"},
+		{"1234567.891", "ch", "1'234'567.891"},
+		{"-1234.5", "de", "-1.234,5"},
+		{"123", "en", "123"},
+		{"1.235e+04", "de", "1,235e+04"},
+		{"12.35 k", "de", "12,35 k"},
+		{"NaN", "de", "NaN"},
+		{"+∞", "de", "+∞"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+" "+tt.number, func(t *testing.T) {
+			assert.Equal(t, tt.want, Localize(tt.number, Locales[tt.locale]))
+		})
+	}
+}
+
+func TestFormatLocale(t *testing.T) {
+	tests := []struct {
+		value     float64
+		mode      string
+		precision int
+		locale    string
+		want      string
+	}{
+		{1234567.891, "auto", 6, "de", "1.234.570"},
+		{1234567.891, "auto", 10, "de", "1.234.567,891"},
+		{1234567.891, "auto", 6, "en", "1,234,570"},
+		{1234567.891, "auto", 6, "plain", "1.23457e+06"},
+		{-98765.4321, "auto", 3, "fr", "-98\u202f800"},
+		{2.5e20, "auto", 6, "de", "2,5e+20"},
+		{1234567.891, "sci", 4, "de", "1,235e6"},
+		{1234.5, "fixed", 2, "ch", "1'234.50"},
+		{0.000012345, "auto", 3, "de", "1,23e-05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+" "+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatLocale(tt.value, tt.mode, tt.precision, Locales[tt.locale]))
+		})
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	assert.Contains(t, Completions("k"), "km")
+	assert.Contains(t, Completions("k"), "kg")
+	assert.Contains(t, Completions("mi"), "mile")
+	assert.NotContains(t, Completions("k"), "m")
+	assert.NotContains(t, Completions("kf"), "kft") // feet take no prefix
+
+	for _, name := range Completions("G") {
+		assert.True(t, IsUnit(name), name)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Workspace Module - Saved Sessions
+=================================
+Part of Axion CLI Calculator
+
+This module saves the state of a calculator session to a JSON file and
+restores it in a later session: the variables (with their units and const
+declarations), the settings in effect and the custom units loaded.
+Workspaces are kept in the workspaces directory below the data directory,
+one file per name (workspaces/<name>.json):
+
+    {
+      "version": 1,
+      "saved": "2025-06-01T09:30:00Z",
+      "variables": [
+        {"name": "d", "value": 42000, "dim": "m", "unit": "km", "scale": 1000},
+        {"name": "rate", "value": 0.05, "const": true}
+      ],
+      "settings": {"precision": 6, "angle": "deg", "display": "auto", ...},
+      "units": [
+        {"units": [{"symbol": "sprint", "definition": "2 wk"}]}
+      ]
+    }
+
+Values are stored in SI base units at full precision ("+∞", "-∞" and "NaN"
+as in history.json), with their dimension and display unit. "units" holds
+every units file loaded in the session, in the units file format; files
+that are already loaded are skipped when a workspace is restored. The
+version number is raised whenever the format changes, and files written by
+a newer version are rejected rather than half-read.
+*/
+
+package workspace
+
+import (
+	"Axion/evaluator"
+	"Axion/history"
+	"Axion/paths"
+	"Axion/settings"
+	"Axion/units"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the format version written by Save
+const Version = 1
+
+// LastSession names the workspace saved on exit when settings.Autosave is on
+const LastSession = "last"
+
+// File is the on-disk form of a workspace
+type File struct {
+	Version   int               `json:"version"`
+	Saved     time.Time         `json:"saved"`
+	Variables []Variable        `json:"variables"`
+	Settings  settings.Config   `json:"settings"`
+	Units     []json.RawMessage `json:"units,omitempty"`
+}
+
+// Variable is a stored variable and its value
+type Variable struct {
+	Name     string            `json:"name"`
+	Value    history.JsonFloat `json:"value"`         // In SI base units
+	Dim      string            `json:"dim,omitempty"` // e.g. "kg*m/s^2"; empty for plain numbers
+	Unit     string            `json:"unit,omitempty"`
+	Scale    float64           `json:"scale,omitempty"`
+	Offset   float64           `json:"offset,omitempty"`
+	Absolute bool              `json:"absolute,omitempty"` // Absolute temperature reading
+	Const    bool              `json:"const,omitempty"`
+}
+
+// Path returns the file of the named workspace; a name containing a path
+// separator or ending in .json is taken as a file path
+func Path(name string) string {
+	if strings.ContainsAny(name, `/\`) || strings.HasSuffix(name, ".json") {
+		return name
+	}
+	if dir := Dir(); dir != "" {
+		return filepath.Join(dir, name+".json")
+	}
+	return name + ".json"
+}
+
+// Dir returns the directory holding named workspaces, or "" if no data
+// directory is available
+func Dir() string {
+	return paths.Data("workspaces")
+}
+
+// Names lists the saved workspaces by name
+func Names() []string {
+	dir := Dir()
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Current captures the variables, settings and custom units in effect
+func Current() File {
+	f := File{
+		Version:  Version,
+		Saved:    time.Now().UTC().Truncate(time.Second),
+		Settings: settings.Current(),
+		Units:    units.Custom(),
+	}
+	for name, q := range evaluator.Vars {
+		f.Variables = append(f.Variables, Variable{
+			Name:     name,
+			Value:    history.JsonFloat(q.Value),
+			Dim:      q.Dim.String(),
+			Unit:     q.Unit,
+			Scale:    q.Scale,
+			Offset:   q.Offset,
+			Absolute: q.Absolute,
+			Const:    evaluator.ReadOnly[name],
+		})
+	}
+	sort.Slice(f.Variables, func(i, j int) bool { return f.Variables[i].Name < f.Variables[j].Name })
+	return f
+}
+
+// Save writes the current session to path, creating parent directories
+func Save(path string) error {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Current()); err != nil {
+		return err
+	}
+	if err := paths.Prepare(path); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
+
+// Read loads a workspace file without applying it
+func Read(path string) (File, error) {
+	var f File
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f, fmt.Errorf("failed to read workspace: %w", err)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse workspace %s: %w", path, err)
+	}
+	switch {
+	case f.Version < 1:
+		return f, fmt.Errorf("workspace %s has no version", path)
+	case f.Version > Version:
+		return f, fmt.Errorf("workspace %s has version %d; this Axion reads up to version %d", path, f.Version, Version)
+	}
+	return f, nil
+}
+
+// Apply loads the workspace's custom units and replaces the variables with
+// its own; the settings are left to the caller, which knows which of them
+// were given on the command line
+func (f File) Apply() error {
+	if _, err := units.LoadCustom(f.Units); err != nil {
+		return fmt.Errorf("failed to restore units: %w", err)
+	}
+
+	vars := make(map[string]units.Quantity)
+	readOnly := make(map[string]bool)
+	for _, v := range f.Variables {
+		dim, err := units.ParseDimension(v.Dim)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+		vars[v.Name] = units.Quantity{
+			Value:    float64(v.Value),
+			Dim:      dim,
+			Unit:     v.Unit,
+			Scale:    v.Scale,
+			Offset:   v.Offset,
+			Absolute: v.Absolute,
+		}
+		if v.Const {
+			readOnly[v.Name] = true
+		}
+	}
+	evaluator.Vars, evaluator.ReadOnly = vars, readOnly
+	return nil
+}
@@ -0,0 +1,141 @@
+package workspace
+
+import (
+	"Axion/evaluator"
+	"Axion/settings"
+	"Axion/units"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quantity evaluates "<value> <unit>" into a quantity shown in unit
+func quantity(t *testing.T, value float64, unit string) units.Quantity {
+	t.Helper()
+	u, ok := units.Lookup(unit)
+	require.True(t, ok, "unknown unit %s", unit)
+	q, err := u.Reading(units.Scalar(value))
+	require.NoError(t, err)
+	return q
+}
+
+// resetSession restores empty variables and default settings after a test
+func resetSession(t *testing.T) {
+	t.Cleanup(func() {
+		evaluator.Vars = make(map[string]units.Quantity)
+		evaluator.ReadOnly = make(map[string]bool)
+		settings.Apply(settings.Defaults())
+	})
+}
+
+func TestSaveReadApply(t *testing.T) {
+	resetSession(t)
+	_, err := units.LoadCustom([]json.RawMessage{
+		json.RawMessage(`{"units":[{"symbol":"sprint","definition":"2 wk"}]}`),
+	})
+	require.NoError(t, err)
+
+	vars := map[string]units.Quantity{
+		"d":     quantity(t, 42, "km"),
+		"temp":  quantity(t, 20, "C"),
+		"speed": {Value: 12.5, Dim: units.Dimension{units.Length: 1, units.Time: -1}},
+		"rate":  units.Scalar(0.05),
+		"big":   units.Scalar(math.Inf(1)),
+		"len":   quantity(t, 3, "sprint"),
+	}
+	evaluator.Vars = make(map[string]units.Quantity)
+	for name, q := range vars {
+		evaluator.Vars[name] = q
+	}
+	evaluator.ReadOnly = map[string]bool{"rate": true}
+	config := settings.Defaults()
+	require.NoError(t, config.Set("precision", "4"))
+	require.NoError(t, config.Set("angle", "rad"))
+	settings.Apply(config)
+
+	path := filepath.Join(t.TempDir(), "nested", "session.json")
+	require.NoError(t, Save(path))
+
+	// Forget the session, then bring it back
+	evaluator.Vars = map[string]units.Quantity{"stale": units.Scalar(1)}
+	evaluator.ReadOnly = make(map[string]bool)
+	settings.Apply(settings.Defaults())
+
+	f, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, Version, f.Version)
+	assert.Len(t, f.Variables, len(vars))
+	assert.Equal(t, 4, f.Settings.Precision)
+	assert.Equal(t, "rad", f.Settings.Angle)
+	assert.Len(t, f.Units, 1)
+
+	require.NoError(t, f.Apply())
+	assert.Equal(t, vars, evaluator.Vars)
+	assert.Equal(t, map[string]bool{"rate": true}, evaluator.ReadOnly)
+	assert.True(t, evaluator.Vars["temp"].Absolute)
+	value, unit := evaluator.Vars["d"].Display()
+	assert.InDelta(t, 42, value, 1e-12)
+	assert.Equal(t, "km", unit)
+}
+
+func TestRead_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"invalid json", `{"version": 1,`, "failed to parse workspace"},
+		{"no version", `{"variables": []}`, "has no version"},
+		{"newer version", `{"version": 99}`, "has version 99"},
+		{"bad dimension", `{"version": 1, "variables": [{"name": "x", "value": 1, "dim": "parsec^"}]}`, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			f, err := Read(path)
+			if tt.message == "" {
+				// Readable, but cannot be applied
+				require.NoError(t, err)
+				assert.Error(t, f.Apply())
+				return
+			}
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+
+	_, err := Read(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("AXION_HOME", t.TempDir())
+	for _, tt := range []struct {
+		name     string
+		expected string
+	}{
+		{"budget", filepath.Join(Dir(), "budget.json")},
+		{"saved.json", "saved.json"},
+		{"./budget", "./budget"},
+		{"/tmp/w.json", "/tmp/w.json"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Path(tt.name))
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	t.Setenv("AXION_HOME", t.TempDir())
+	resetSession(t)
+	evaluator.Vars = map[string]units.Quantity{"x": units.Scalar(1)}
+	for _, name := range []string{"work", "home"} {
+		require.NoError(t, Save(Path(name)))
+	}
+	assert.Equal(t, []string{"home", "work"}, Names())
+}